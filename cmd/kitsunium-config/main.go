@@ -0,0 +1,564 @@
+// Command kitsunium-config inspects the configuration a service would load.
+//
+// Usage:
+//
+//	kitsunium-config <command> [flags] [arguments]
+//
+// Commands:
+//
+//	print              print the effective merged configuration
+//	get <key>          print the value of a key
+//	explain <key>      show which source provided a key
+//	validate           validate the configuration against a schema
+//	convert            convert the configuration to another format
+//	diff <a> <b>       compare two configuration sources
+//	generate           generate documentation or a sample file from a schema
+//
+// Sources are given with -f, in increasing priority order, and can be JSON,
+// YAML, XML, TOML or dotenv (.env) files or HTTP(S) URLs; other file extensions
+// are rejected. -env adds the environment as the lowest priority source. Every command accepts -json for machine-readable output.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/kistunium/sdk/pkg/kernel/config/encode"
+	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/kistunium/sdk/pkg/kernel/config/secret"
+)
+
+// command is a subcommand of the tool. It returns the process exit code.
+type command func(opts *options, args []string, stdout io.Writer) (int, error)
+
+var commands = map[string]command{
+	"print":    printCommand,
+	"get":      getCommand,
+	"explain":  explainCommand,
+	"validate": validateCommand,
+	"convert":  convertCommand,
+	"diff":     diffCommand,
+	"generate": generateCommand,
+}
+
+// options holds the flags shared by every command.
+type options struct {
+	files   list
+	env     bool
+	json    bool
+	reveal  bool
+	keyFile string
+	keyEnv  string
+	schema  string
+	to      string
+	out     string
+}
+
+// list is a repeatable string flag.
+type list []string
+
+func (l *list) String() string     { return strings.Join(*l, ",") }
+func (l *list) Set(v string) error { *l = append(*l, v); return nil }
+
+// entry is the machine-readable form of a configuration value.
+type entry struct {
+	Key    string `json:"key"`
+	Value  any    `json:"value"`
+	Source string `json:"source,omitempty"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run Executes the tool
+//
+// Parameters:
+// - args: []string - the command line, without the program name
+// - stdout: io.Writer - the destination of the command output
+// - stderr: io.Writer - the destination of usage and error messages
+//
+// Returns:
+// - int: the process exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || commands[args[0]] == nil {
+		usage(stderr)
+		return 2
+	}
+
+	opts := &options{}
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Var(&opts.files, "f", "configuration `source` (file or URL), repeatable, lowest priority first")
+	flags.BoolVar(&opts.env, "env", false, "load the environment as the lowest priority source")
+	flags.BoolVar(&opts.json, "json", false, "write machine-readable JSON output")
+	flags.BoolVar(&opts.reveal, "reveal", false, "show the values of secret keys")
+	flags.StringVar(&opts.keyFile, "key-file", "", "`file` holding the key of encrypted values")
+	flags.StringVar(&opts.keyEnv, "key-env", "", "environment `variable` holding the key of encrypted values")
+	flags.StringVar(&opts.schema, "schema", "", "schema `file` used by validate and generate")
+	flags.StringVar(&opts.to, "to", "", "output `format` of convert ("+strings.Join(encode.Formats, ", ")+
+		") or generate ("+strings.Join(generateFormats, ", ")+")")
+	flags.StringVar(&opts.out, "o", "", "output `file` of convert and generate, standard output when empty")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	code, err := commands[args[0]](opts, flags.Args(), stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "kitsunium-config %s: %v\n", args[0], err)
+	}
+
+	return code
+}
+
+// usage Writes the list of commands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: kitsunium-config <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  print              print the effective merged configuration")
+	fmt.Fprintln(w, "  get <key>          print the value of a key")
+	fmt.Fprintln(w, "  explain <key>      show which source provided a key")
+	fmt.Fprintln(w, "  validate           validate the configuration against -schema")
+	fmt.Fprintln(w, "  convert            convert the configuration to -to format")
+	fmt.Fprintln(w, "  diff <a> <b>       compare two configuration sources")
+	fmt.Fprintln(w, "  generate           generate documentation or a sample of -schema in -to format")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run 'kitsunium-config <command> -h' for the flags")
+}
+
+// printCommand Prints every key of the effective configuration
+func printCommand(opts *options, args []string, stdout io.Writer) (int, error) {
+	parsers, err := opts.parsers(opts.files...)
+	if err != nil {
+		return 2, err
+	}
+
+	c, err := opts.load(parsers)
+	if err != nil {
+		return 1, err
+	}
+
+	snapshot := c.Snapshot()
+	entries := make([]entry, 0, snapshot.Len())
+	for _, key := range snapshot.Keys() {
+		entries = append(entries, entry{Key: key, Value: opts.value(snapshot, key), Source: snapshot.Source(key)})
+	}
+
+	if opts.json {
+		return 0, writeJSON(stdout, entries)
+	}
+
+	for _, e := range entries {
+		fmt.Fprintf(stdout, "%s = %v  # %s\n", e.Key, e.Value, e.Source)
+	}
+
+	return 0, nil
+}
+
+// getCommand Prints the value of a single key
+func getCommand(opts *options, args []string, stdout io.Writer) (int, error) {
+	if len(args) != 1 {
+		return 2, errors.New("expected exactly one key")
+	}
+
+	key := normalize.Key(args[0])
+
+	parsers, err := opts.parsers(opts.files...)
+	if err != nil {
+		return 2, err
+	}
+
+	c, err := opts.load(parsers)
+	if err != nil {
+		return 1, err
+	}
+
+	snapshot := c.Snapshot()
+	if !snapshot.Has(key) {
+		return 1, fmt.Errorf("key %q not found", key)
+	}
+
+	e := entry{Key: key, Value: opts.value(snapshot, key), Source: snapshot.Source(key)}
+	if opts.json {
+		return 0, writeJSON(stdout, e)
+	}
+
+	fmt.Fprintln(stdout, e.Value)
+
+	return 0, nil
+}
+
+// candidate is a value of a key provided by one of the sources.
+type candidate struct {
+	Source   string `json:"source"`
+	Location string `json:"location,omitempty"`
+	Value    any    `json:"value"`
+}
+
+// explainCommand Shows every source defining a key and the one that won
+//
+// Each source is loaded on its own through a Config, so candidates are
+// decrypted and renamed exactly as in the merged configuration.
+func explainCommand(opts *options, args []string, stdout io.Writer) (int, error) {
+	if len(args) != 1 {
+		return 2, errors.New("expected exactly one key")
+	}
+	key := normalize.Key(args[0])
+
+	parsers, err := opts.parsers(opts.files...)
+	if err != nil {
+		return 2, err
+	}
+
+	merged, err := opts.load(parsers)
+	if err != nil {
+		return 1, err
+	}
+
+	candidates := []candidate{}
+	for _, p := range parsers {
+		c, err := opts.load([]config.Parser{p})
+		if err != nil {
+			return 1, err
+		}
+
+		snapshot := c.Snapshot()
+		if !snapshot.Has(key) {
+			continue
+		}
+
+		// A value is redacted in every source when it is secret in one of them.
+		value := snapshot.Get(key, nil)
+		if !opts.reveal && (snapshot.Secret(key) || merged.Snapshot().Secret(key)) {
+			value = config.Redacted
+		}
+		candidates = append(candidates, candidate{Source: p.Type(), Location: location(p), Value: value})
+	}
+
+	if len(candidates) == 0 {
+		return 1, fmt.Errorf("key %q is not provided by any source", key)
+	}
+
+	winner := candidates[len(candidates)-1]
+
+	if opts.json {
+		return 0, writeJSON(stdout, map[string]any{
+			"key":        key,
+			"value":      winner.Value,
+			"source":     winner.Source,
+			"location":   winner.Location,
+			"candidates": candidates,
+		})
+	}
+
+	fmt.Fprintf(stdout, "%s = %v\n", key, winner.Value)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		status := "overridden"
+		if i == len(candidates)-1 {
+			status = "winner"
+		}
+		fmt.Fprintf(stdout, "  %-10s %s %s = %v\n", status, candidates[i].Source, candidates[i].Location, candidates[i].Value)
+	}
+
+	return 0, nil
+}
+
+// validateCommand Validates the effective configuration against a schema
+func validateCommand(opts *options, args []string, stdout io.Writer) (int, error) {
+	if opts.schema == "" {
+		return 2, errors.New("-schema is required")
+	}
+
+	schema, err := config.LoadSchema(opts.schema)
+	if err != nil {
+		return 1, err
+	}
+
+	parsers, err := opts.parsers(opts.files...)
+	if err != nil {
+		return 2, err
+	}
+
+	c, err := opts.load(parsers)
+	if err != nil {
+		return 1, err
+	}
+
+	failures := []*config.ValidationError{}
+	if err := schema.Validate(c); err != nil {
+		for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+			var failure *config.ValidationError
+			if errors.As(e, &failure) {
+				failures = append(failures, failure)
+			}
+		}
+	}
+
+	code := 0
+	if len(failures) > 0 {
+		code = 1
+	}
+
+	if opts.json {
+		return code, writeJSON(stdout, map[string]any{"valid": code == 0, "errors": failures})
+	}
+
+	for _, failure := range failures {
+		fmt.Fprintln(stdout, failure)
+	}
+	if code == 0 {
+		fmt.Fprintln(stdout, "configuration is valid")
+	}
+
+	return code, nil
+}
+
+// convertCommand Writes the effective configuration in another format
+func convertCommand(opts *options, args []string, stdout io.Writer) (int, error) {
+	if opts.to == "" {
+		return 2, errors.New("-to is required")
+	}
+
+	parsers, err := opts.parsers(opts.files...)
+	if err != nil {
+		return 2, err
+	}
+
+	c, err := opts.load(parsers)
+	if err != nil {
+		return 1, err
+	}
+
+	snapshot := c.Snapshot()
+	data := map[string]string{}
+	for _, key := range snapshot.Keys() {
+		data[key] = fmt.Sprint(opts.value(snapshot, key))
+	}
+
+	w, closer, err := opts.output(stdout)
+	if err != nil {
+		return 1, err
+	}
+
+	err = encode.Write(w, opts.to, data)
+	if closeErr := closer(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+// generateFormats lists the formats of the generate command.
+var generateFormats = append([]string{"markdown", "env", "jsonschema"}, config.SampleFormats...)
+
+// generateCommand Writes the reference documentation, a sample file or the
+// JSON Schema of the keys declared in a schema
+func generateCommand(opts *options, args []string, stdout io.Writer) (int, error) {
+	if opts.schema == "" {
+		return 2, errors.New("-schema is required")
+	}
+
+	if opts.to == "" {
+		return 2, errors.New("-to is required")
+	}
+
+	schema, err := config.LoadSchema(opts.schema)
+	if err != nil {
+		return 1, err
+	}
+
+	w, closer, err := opts.output(stdout)
+	if err != nil {
+		return 1, err
+	}
+
+	switch opts.to {
+	case "markdown", "md":
+		err = schema.Markdown(w)
+	case "env":
+		err = schema.EnvExample(w)
+	case "jsonschema":
+		err = schema.JSONSchema(w)
+	default:
+		err = schema.Sample(w, opts.to)
+	}
+
+	if closeErr := closer(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return 1, err
+	}
+
+	return 0, nil
+}
+
+// diffCommand Compares two sources, exiting with 1 when they differ
+func diffCommand(opts *options, args []string, stdout io.Writer) (int, error) {
+	if len(args) != 2 {
+		return 2, errors.New("expected two sources")
+	}
+
+	parsers, err := opts.parsers(args[0])
+	if err != nil {
+		return 2, err
+	}
+
+	a, err := opts.load(parsers)
+	if err != nil {
+		return 2, err
+	}
+
+	parsers, err = opts.parsers(args[1])
+	if err != nil {
+		return 2, err
+	}
+
+	b, err := opts.load(parsers)
+	if err != nil {
+		return 2, err
+	}
+
+	changes := config.Diff(a, b)
+
+	code := 0
+	if len(changes) > 0 {
+		code = 1
+	}
+
+	if opts.json {
+		content, err := changes.JSON()
+		if err != nil {
+			return 2, err
+		}
+		fmt.Fprintln(stdout, string(content))
+		return code, nil
+	}
+
+	fmt.Fprint(stdout, changes.Unified(args[0], args[1]))
+
+	return code, nil
+}
+
+// parsers Builds the parser chain of a list of sources
+//
+// Parameters:
+// - sources: ...string - files or URLs, lowest priority first
+//
+// Returns:
+// - []config.Parser: the environment parser when -env is set, then one parser
+// per source
+// - error: error if the extension of a file is not a supported format
+func (o *options) parsers(sources ...string) ([]config.Parser, error) {
+	parsers := []config.Parser{}
+	if o.env {
+		parsers = append(parsers, &parser.ENV{})
+	}
+
+	for _, source := range sources {
+		switch ext := path.Ext(source); {
+		case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+			parsers = append(parsers, &parser.HTTP{URL: source})
+		case ext == ".json" || ext == ".jsonc" || ext == ".json5":
+			parsers = append(parsers, &parser.JSON{Path: source})
+		case ext == ".yaml" || ext == ".yml":
+			parsers = append(parsers, &parser.YAML{Path: source})
+		case ext == ".xml":
+			parsers = append(parsers, &parser.XML{Path: source})
+		case ext == ".toml":
+			parsers = append(parsers, &parser.TOML{Path: source})
+		case ext == ".env":
+			parsers = append(parsers, &parser.ENV{Path: source})
+		default:
+			return nil, fmt.Errorf("unsupported input format %q: %s", ext, source)
+		}
+	}
+
+	return parsers, nil
+}
+
+// output Returns the destination of the command output, the -o file when set,
+// and the function closing it, whose error must be reported
+func (o *options) output(stdout io.Writer) (io.Writer, func() error, error) {
+	if o.out == "" {
+		return stdout, func() error { return nil }, nil
+	}
+
+	file, err := os.Create(o.out)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, file.Close, nil
+}
+
+// load Loads a parser chain, with the decryption key of the flags
+func (o *options) load(parsers []config.Parser) (*config.Config, error) {
+	c := config.New(parsers...)
+
+	switch {
+	case o.keyFile != "":
+		c.SetKeyProvider(&secret.FileKey{Path: o.keyFile})
+	case o.keyEnv != "":
+		c.SetKeyProvider(&secret.EnvKey{Name: o.keyEnv})
+	}
+
+	if err := c.Load(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// value Returns the value of a key, redacted for secret keys unless -reveal
+// is set
+func (o *options) value(snapshot *config.Snapshot, key string) any {
+	if !o.reveal && snapshot.Secret(key) {
+		return config.Redacted
+	}
+
+	return snapshot.Get(key, nil)
+}
+
+// location Returns the file or URL read by a parser
+func location(p config.Parser) string {
+	switch p := p.(type) {
+	case *parser.JSON:
+		return p.Path
+	case *parser.YAML:
+		return p.Path
+	case *parser.XML:
+		return p.Path
+	case *parser.TOML:
+		return p.Path
+	case *parser.ENV:
+		return p.Path
+	case *parser.HTTP:
+		return p.URL
+	default:
+		return ""
+	}
+}
+
+// writeJSON Writes an indented JSON document
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
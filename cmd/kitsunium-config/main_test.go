@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	return dir
+}
+
+func execute(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCommands(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yaml":   "db:\n  host: localhost\n  port: 5432\n  password: s3cr3t\n",
+		"prod.json":   `{"db": {"host": "prod.local"}}`,
+		"schema.json": `{"keys": [{"key": "db.port", "type": "int"}, {"key": "db.user", "required": true}]}`,
+		"secret.json": `{"keys": [{"key": "db.password", "type": "int"}, {"key": "db.host", "enum": ["a"]}]}`,
+	})
+	base, prod := filepath.Join(dir, "base.yaml"), filepath.Join(dir, "prod.json")
+
+	key := make([]byte, secret.KeySize)
+	t.Setenv("CLI_TEST_KEY", hex.EncodeToString(key))
+	password, err := secret.Encrypt(key, "0ther")
+	assert.NoError(t, err)
+	encrypted := filepath.Join(dir, "encrypted.json")
+	assert.NoError(t, os.WriteFile(encrypted, []byte(`{"db": {"password": "`+password+`"}}`), 0o600))
+
+	t.Run("print", func(t *testing.T) {
+		code, stdout, _ := execute("print", "-f", base, "-f", prod)
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "db.host = prod.local  # json\ndb.password = [REDACTED]  # yaml\ndb.port = 5432  # yaml\n", stdout)
+
+		code, stdout, _ = execute("print", "-f", base, "-json", "-reveal")
+		assert.Equal(t, 0, code)
+
+		var entries []entry
+		assert.NoError(t, json.Unmarshal([]byte(stdout), &entries))
+		assert.Equal(t, entry{Key: "db.password", Value: "s3cr3t", Source: "yaml"}, entries[1])
+	})
+
+	t.Run("get", func(t *testing.T) {
+		code, stdout, _ := execute("get", "-f", base, "-f", prod, "db.host")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "prod.local\n", stdout)
+
+		code, stdout, _ = execute("get", "-f", base, "DB.Port")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "5432\n", stdout)
+
+		code, _, stderr := execute("get", "-f", base, "db.missing")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "not found")
+	})
+
+	t.Run("explain", func(t *testing.T) {
+		code, stdout, _ := execute("explain", "-f", base, "-f", prod, "-json", "db.host")
+		assert.Equal(t, 0, code)
+
+		var explained struct {
+			Source     string      `json:"source"`
+			Location   string      `json:"location"`
+			Candidates []candidate `json:"candidates"`
+		}
+		assert.NoError(t, json.Unmarshal([]byte(stdout), &explained))
+		assert.Equal(t, "json", explained.Source)
+		assert.Equal(t, prod, explained.Location)
+		assert.Len(t, explained.Candidates, 2)
+
+		code, stdout, _ = execute("explain", "-f", base, "-f", encrypted, "-key-env", "CLI_TEST_KEY", "db.password")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "db.password = [REDACTED]\n  winner     json "+encrypted+" = [REDACTED]\n"+
+			"  overridden yaml "+base+" = [REDACTED]\n", stdout)
+
+		code, stdout, _ = execute("explain", "-f", base, "-f", encrypted, "-key-env", "CLI_TEST_KEY", "-reveal", "-json", "DB.Password")
+		assert.Equal(t, 0, code)
+		assert.NoError(t, json.Unmarshal([]byte(stdout), &explained))
+		assert.Equal(t, "0ther", explained.Candidates[1].Value)
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		code, stdout, _ := execute("validate", "-f", base, "-schema", filepath.Join(dir, "schema.json"))
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "db.user: required key is missing\n", stdout)
+
+		// Values of secret keys are never printed.
+		code, stdout, _ = execute("validate", "-f", base, "-schema", filepath.Join(dir, "secret.json"))
+		assert.Equal(t, 1, code)
+		assert.Equal(t, "db.password: invalid int [REDACTED]\n"+
+			"db.host: value \"localhost\" is not one of [a]\n", stdout)
+
+		code, stdout, _ = execute("validate", "-f", base, "-schema", filepath.Join(dir, "secret.json"), "-json")
+		assert.Equal(t, 1, code)
+		assert.NotContains(t, stdout, "s3cr3t")
+	})
+
+	t.Run("convert", func(t *testing.T) {
+		code, stdout, _ := execute("convert", "-f", base, "-f", prod, "-to", "env")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "DB_HOST=prod.local\nDB_PASSWORD=[REDACTED]\nDB_PORT=5432\n", stdout)
+
+		code, stdout, _ = execute("convert", "-f", base, "-f", prod, "-to", "toml", "-reveal")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "[db]\nhost = \"prod.local\"\npassword = \"s3cr3t\"\nport = \"5432\"\n", stdout)
+
+		code, _, stderr := execute("convert", "-f", base, "-to", "json", "-o", filepath.Join(dir, "missing", "out.json"))
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "no such file")
+	})
+
+	t.Run("inputs", func(t *testing.T) {
+		// TOML and dotenv outputs are read back as inputs.
+		for _, format := range []string{"toml", "env"} {
+			out := filepath.Join(dir, "converted."+format)
+			code, _, _ := execute("convert", "-f", base, "-f", prod, "-to", format, "-reveal", "-o", out)
+			assert.Equal(t, 0, code)
+
+			code, stdout, _ := execute("print", "-f", out, "-reveal")
+			assert.Equal(t, 0, code)
+			assert.Equal(t, "db.host = prod.local  # "+format+"\ndb.password = s3cr3t  # "+format+
+				"\ndb.port = 5432  # "+format+"\n", stdout)
+		}
+
+		code, _, stderr := execute("print", "-f", filepath.Join(dir, "config.ini"))
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, `unsupported input format ".ini"`)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		code, stdout, _ := execute("diff", base, prod)
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stdout, "-db.host = localhost  # yaml\n+db.host = prod.local  # json\n")
+
+		code, stdout, _ = execute("diff", base, base)
+		assert.Equal(t, 0, code)
+		assert.Empty(t, stdout)
+	})
+
+	t.Run("generate", func(t *testing.T) {
+		code, stdout, _ := execute("generate", "-schema", filepath.Join(dir, "schema.json"), "-to", "env")
+		assert.Equal(t, 0, code)
+		assert.Equal(t, "DB_PORT=\n\n# Required.\nDB_USER=\n", stdout)
+
+		out := filepath.Join(dir, "config.yaml")
+		code, _, _ = execute("generate", "-schema", filepath.Join(dir, "schema.json"), "-to", "yaml", "-o", out)
+		assert.Equal(t, 0, code)
+
+		content, err := os.ReadFile(out)
+		assert.NoError(t, err)
+		assert.Equal(t, "db:\n  port: \"\"\n  # Required.\n  user: \"\"\n", string(content))
+
+		code, _, stderr := execute("generate", "-schema", filepath.Join(dir, "schema.json"), "-to", "toml")
+		assert.Equal(t, 1, code)
+		assert.Contains(t, stderr, "unsupported format")
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		code, _, stderr := execute("unknown")
+		assert.Equal(t, 2, code)
+		assert.Contains(t, stderr, "usage")
+	})
+}
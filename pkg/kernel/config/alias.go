@@ -0,0 +1,253 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Logger receives the warnings of a Config. It is satisfied by *slog.Logger.
+type Logger interface {
+	Warn(msg string, args ...any)
+}
+
+// Deprecation describes a key that should no longer be used.
+type Deprecation struct {
+	// Replacement is the key serving the values of the deprecated key, empty
+	// when the key is deprecated without replacement.
+	Replacement string
+	// Message is added to the warning, e.g. the release removing the key.
+	Message string
+	// Removal is the date after which the key is rejected in strict mode. The
+	// key is never rejected when zero.
+	Removal time.Time
+}
+
+// SetLogger sets the logger receiving the warnings, slog.Default() when nil
+//
+// Parameters:
+// - logger: Logger - The destination of the warnings
+func (c *Config) SetLogger(logger Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.logger = logger
+}
+
+// RegisterAlias renames a key
+//
+// Values loaded or set under the old key, or under keys nested in it, are
+// served under the new key, and reads of the old key return the values of the
+// new one. A warning is logged once per deprecated key, whether it is loaded,
+// set or read. A value loaded under the new key by the same parser takes
+// precedence.
+//
+// Parameters:
+// - old: string - The deprecated key
+// - new: string - The key replacing it
+func (c *Config) RegisterAlias(old, new string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deprecation := c.deprecations[old]
+	deprecation.Replacement = new
+
+	c.deprecate(old, deprecation)
+}
+
+// Deprecate registers the deprecation of a key
+//
+// Parameters:
+// - key: string - The deprecated key
+// - deprecation: Deprecation - The replacement and removal date of the key
+func (c *Config) Deprecate(key string, deprecation Deprecation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.deprecate(key, deprecation)
+}
+
+// SetStrictDeprecations makes Load fail when a source provides a deprecated
+// key after its removal date
+//
+// Parameters:
+// - strict: bool - true to reject removed keys, false to only warn about them
+func (c *Config) SetStrictDeprecations(strict bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.strictDeprecations = strict
+}
+
+// deprecate Registers a deprecation, c.mu must be held
+//
+// The deprecations are copied on write since published snapshots share them.
+func (c *Config) deprecate(key string, deprecation Deprecation) {
+	deprecations := maps.Clone(c.deprecations)
+	if deprecations == nil {
+		deprecations = map[string]Deprecation{}
+	}
+	deprecations[key] = deprecation
+	c.deprecations = deprecations
+
+	next := c.Snapshot().clone()
+	c.bind(next)
+	c.data.Store(next)
+}
+
+// alias Moves the values of deprecated keys to their replacement
+//
+// The maps holding deprecated keys are replaced by renamed copies, the maps
+// returned by the parsers are never modified.
+//
+// Parameters:
+// - loaded: []map[string]string - The values returned by the parsers
+//
+// Returns:
+// - error: error if a key is past its removal date in strict mode
+func (c *Config) alias(loaded []map[string]string) error {
+	c.mu.Lock()
+	deprecations := maps.Clone(c.deprecations)
+	strict := c.strictDeprecations
+	c.mu.Unlock()
+
+	if len(deprecations) == 0 {
+		return nil
+	}
+
+	for i, data := range loaded {
+		renamed := map[string]string{}
+		var removed []string
+
+		for _, key := range slices.Sorted(maps.Keys(data)) {
+			old, deprecation, ok := deprecated(deprecations, key)
+			if !ok {
+				continue
+			}
+
+			source := c.parsers[i].Type()
+			if strict && !deprecation.Removal.IsZero() && time.Now().After(deprecation.Removal) {
+				return fmt.Errorf("key %q from %s was removed on %s%s", key, source,
+					deprecation.Removal.Format(time.DateOnly), deprecation.hint())
+			}
+
+			c.warnDeprecated(old, deprecation, "key", key, "source", source)
+
+			if deprecation.Replacement != "" {
+				renamed[deprecation.Replacement+key[len(old):]] = data[key]
+				removed = append(removed, key)
+			}
+		}
+
+		if len(removed) == 0 {
+			continue
+		}
+
+		next := maps.Clone(data)
+		for _, key := range removed {
+			delete(next, key)
+		}
+		for key, value := range renamed {
+			if _, exists := data[key]; !exists {
+				next[key] = value
+			}
+		}
+		loaded[i] = next
+	}
+
+	return nil
+}
+
+// warn Logs a warning with the logger set by SetLogger
+func (c *Config) warn(msg string, args ...any) {
+	c.mu.Lock()
+	logger := c.logger
+	c.mu.Unlock()
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger.Warn(msg, args...)
+}
+
+// warnOnce Reports whether a warning identified by a key has not been logged
+// yet
+func (c *Config) warnOnce(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.warned[key] {
+		return false
+	}
+
+	if c.warned == nil {
+		c.warned = map[string]bool{}
+	}
+	c.warned[key] = true
+
+	return true
+}
+
+// warnDeprecated Logs the warning of a deprecated key once, c.mu must not be
+// held
+//
+// Parameters:
+// - old: string - The deprecated key or section
+// - deprecation: Deprecation - Its deprecation
+// - args: ...any - The attributes describing the use of the key
+func (c *Config) warnDeprecated(old string, deprecation Deprecation, args ...any) {
+	if !c.warnOnce("deprecated:" + old) {
+		return
+	}
+
+	if deprecation.Replacement != "" {
+		args = append(args, "replacement", deprecation.Replacement)
+	}
+	if deprecation.Message != "" {
+		args = append(args, "message", deprecation.Message)
+	}
+	c.warn("deprecated configuration key", args...)
+}
+
+// bind Attaches the secret patterns and the deprecations of the Config to a
+// snapshot before it is published, c.mu must be held
+func (c *Config) bind(s *Snapshot) {
+	s.patterns = c.secretPatterns
+	s.deprecations = c.deprecations
+	s.onDeprecated = func(key, old string, deprecation Deprecation) {
+		c.warnDeprecated(old, deprecation, "key", key)
+	}
+}
+
+// deprecated Finds the deprecation of a key or of a section holding it
+//
+// Returns:
+// - string: the deprecated key or section
+// - Deprecation: its deprecation
+// - bool: true if the key is deprecated
+func deprecated(deprecations map[string]Deprecation, key string) (string, Deprecation, bool) {
+	for old := key; ; {
+		if deprecation, ok := deprecations[old]; ok {
+			return old, deprecation, true
+		}
+
+		i := strings.LastIndexByte(old, '.')
+		if i < 0 {
+			return "", Deprecation{}, false
+		}
+		old = old[:i]
+	}
+}
+
+// hint Returns the replacement advice of an error message
+func (d Deprecation) hint() string {
+	if d.Replacement == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(", use %q", d.Replacement)
+}
@@ -0,0 +1,95 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingLogger struct {
+	warnings [][]any
+}
+
+func (l *recordingLogger) Warn(msg string, args ...any) {
+	l.warnings = append(l.warnings, append([]any{msg}, args...))
+}
+
+func TestRegisterAlias(t *testing.T) {
+	logger := &recordingLogger{}
+	c := config.New(
+		staticParser{"db.host": "old.local", "db.port": "5432", "legacy": "x"},
+		staticParser{"db.user": "admin"},
+	)
+	c.SetLogger(logger)
+	c.RegisterAlias("db", "database")
+	c.Deprecate("legacy", config.Deprecation{Message: "removed in v3"})
+
+	assert.NoError(t, c.Load())
+	assert.NoError(t, c.Load())
+
+	snapshot := c.Snapshot()
+	assert.Equal(t, []string{"database.host", "database.port", "database.user", "legacy"}, snapshot.Keys())
+	assert.Equal(t, "static", snapshot.Source("database.host"))
+	assert.Equal(t, [][]any{
+		{"deprecated configuration key", "key", "db.host", "source", "static", "replacement", "database"},
+		{"deprecated configuration key", "key", "legacy", "source", "static", "message", "removed in v3"},
+	}, logger.warnings)
+
+	c.Set("db.host", "set.local")
+	assert.Equal(t, "set.local", c.Get("database.host", nil))
+	assert.NotContains(t, c.Snapshot().Keys(), "db.host")
+
+	// Reads of the deprecated key are served by its replacement.
+	assert.Equal(t, "set.local", c.Get("db.host", nil))
+	assert.True(t, c.Snapshot().Has("db.port"))
+	assert.Equal(t, "set", c.Snapshot().Source("db.host"))
+	assert.Len(t, logger.warnings, 2)
+}
+
+func TestRegisterAliasReads(t *testing.T) {
+	logger := &recordingLogger{}
+	c := config.New(staticParser{"database.host": "new.local"})
+	c.SetLogger(logger)
+	assert.NoError(t, c.Load())
+
+	snapshot := c.Snapshot()
+	c.RegisterAlias("db", "database")
+
+	assert.False(t, snapshot.Has("db.host"))
+	assert.Equal(t, "new.local", c.Get("db.host", nil))
+	assert.True(t, c.Snapshot().Has("db.host"))
+	assert.Equal(t, "static", c.Snapshot().Source("db.host"))
+	assert.Equal(t, [][]any{
+		{"deprecated configuration key", "key", "db.host", "replacement", "database"},
+	}, logger.warnings)
+
+	c.Set("db.port", "5432")
+	assert.Equal(t, "5432", c.Get("database.port", nil))
+	assert.Len(t, logger.warnings, 1)
+}
+
+func TestRegisterAliasPrecedence(t *testing.T) {
+	c := config.New(staticParser{"db.host": "old.local", "database.host": "new.local"})
+	c.SetLogger(&recordingLogger{})
+	c.RegisterAlias("db.host", "database.host")
+
+	assert.NoError(t, c.Load())
+	assert.Equal(t, "new.local", c.Get("database.host", nil))
+}
+
+func TestStrictDeprecations(t *testing.T) {
+	c := config.New(staticParser{"db.host": "old.local"})
+	c.SetLogger(&recordingLogger{})
+	c.RegisterAlias("db.host", "database.host")
+	c.Deprecate("db.host", config.Deprecation{Replacement: "database.host", Removal: time.Now().Add(-time.Hour)})
+
+	assert.NoError(t, c.Load())
+
+	c.SetStrictDeprecations(true)
+	assert.ErrorContains(t, c.Load(), `key "db.host" from static was removed on`)
+
+	c.Deprecate("db.host", config.Deprecation{Replacement: "database.host", Removal: time.Now().Add(time.Hour)})
+	assert.NoError(t, c.Load())
+}
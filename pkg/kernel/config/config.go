@@ -1,7 +1,13 @@
 package config
 
 import (
+	"fmt"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/secret"
 )
 
 type Parser interface {
@@ -10,67 +16,256 @@ type Parser interface {
 }
 
 type Config struct {
-	parsers []Parser
-	data    map[string]any
-	mu      sync.RWMutex
+	parsers        []Parser
+	keys           secret.KeyProvider
+	secretPatterns []string
+	specs          map[string]Spec
+	// defaults and overrides are the layers of the values set by SetDefault or
+	// Declare and by Set, applied below and above the parsers on each load.
+	defaults  map[string]any
+	overrides map[string]any
+	data      atomic.Pointer[Snapshot]
+	mu        sync.Mutex
+	loading   sync.Mutex
+
+	logger             Logger
+	deprecations       map[string]Deprecation
+	strictDeprecations bool
+	strict             *Strict
+	warned             map[string]bool
+
+	loaded       bool
+	version      uint64
+	history      []Revision
+	historyLimit int
+	historyDir   string
 }
 
 // New creates a new Config instance
 func New(parsers ...Parser) *Config {
-	return &Config{
+	c := &Config{
 		parsers: parsers,
-		data:    make(map[string]any),
 	}
+	c.data.Store(emptySnapshot)
+
+	return c
+}
+
+// SetKeyProvider sets the provider of the key used to decrypt encrypted values
+//
+// Parameters:
+// - provider: secret.KeyProvider - The provider of the decryption key
+func (c *Config) SetKeyProvider(provider secret.KeyProvider) {
+	c.loading.Lock()
+	defer c.loading.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.keys = provider
+}
+
+// SetSecretPatterns sets the words identifying the keys holding secret values
+//
+// Values of secret keys are redacted by Diff and encrypted in the persisted
+// history. The patterns apply to the current snapshot and the following ones,
+// see IsSecret for how they are matched.
+//
+// Parameters:
+// - patterns: ...string - The words identifying a secret, DefaultSecretPatterns
+// when empty
+func (c *Config) SetSecretPatterns(patterns ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.secretPatterns = slices.Clone(patterns)
+
+	next := c.Snapshot().clone()
+	c.bind(next)
+	c.data.Store(next)
 }
 
 // Load loads configuration from a list of sources with a priority order
 //
+// Every source is read before anything is published, so readers either see the
+// configuration as it was before the call or the fully loaded one, never a mix.
+// Each load builds the configuration again from the defaults, the values
+// returned by the parsers and the values set with Set, in that order of
+// priority, so a key removed from every source is removed from the
+// configuration. Values of deprecated keys are moved to their replacement, see RegisterAlias,
+// and unknown keys are reported in strict mode, see SetStrict.
+// Encrypted values (ENC[AES256_GCM,...]) are decrypted with the key of the
+// provider set by SetKeyProvider.
+//
 // Parameters:
 // - sources: ...Source - A list of sources to load configuration from
 //
 // Returns:
 // - err: error - Error if any issue occurs during loading
 func (c *Config) Load() error {
+	c.loading.Lock()
+	defer c.loading.Unlock()
+
+	loaded := make([]map[string]string, 0, len(c.parsers))
 	for _, source := range c.parsers {
 		data, err := source.Load()
 		if err != nil {
 			return err
 		}
 
+		loaded = append(loaded, data)
+	}
+
+	if err := c.alias(loaded); err != nil {
+		return err
+	}
+
+	if err := c.unknown(loaded); err != nil {
+		return err
+	}
+
+	decrypted, err := c.decrypt(loaded)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	next := &Snapshot{data: map[string]any{}, sources: map[string]string{}, secrets: decrypted}
+	c.bind(next)
+	for key, value := range c.defaults {
+		next.data[key] = value
+		next.sources[key] = SourceDefault
+	}
+
+	for i, data := range loaded {
 		for key, value := range data {
-			c.Set(key, value)
+			next.data[key] = value
+			next.sources[key] = c.parsers[i].Type()
 		}
 	}
 
+	for key, value := range c.overrides {
+		next.data[key] = value
+		next.sources[key] = "set"
+		delete(next.secrets, key)
+	}
+
+	trigger := TriggerLoad
+	if c.loaded {
+		trigger = TriggerReload
+	}
+	c.loaded = true
+
+	c.publish(next, trigger)
+
 	return nil
 }
 
 // Set sets a value in the configuration
 //
+// A deprecated key registered with RegisterAlias is set under its replacement.
+// The value takes precedence over the parsers and is kept by later loads.
+//
 // Parameters:
 // - key: string - The configuration key to set
 // - value: any - The configuration value to set
 func (c *Config) Set(key string, value any) {
+	key = c.Snapshot().resolve(key)
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.data[key] = value
+	if c.overrides == nil {
+		c.overrides = map[string]any{}
+	}
+	c.overrides[key] = value
+
+	next := c.Snapshot().clone()
+	next.data[key] = value
+	next.sources[key] = "set"
+	delete(next.secrets, key)
+
+	c.publish(next, TriggerSet)
 }
 
 // Get retrieves a value from the configuration
 //
+// A deprecated key registered with RegisterAlias is read from its replacement.
+//
 // Parameters:
 // - key: string - The configuration key to retrieve
 //
 // Returns:
 // - value: any - The configuration value
 func (c *Config) Get(key string, defaultValue any) any {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	return c.Snapshot().Get(key, defaultValue)
+}
+
+// Snapshot returns an immutable view of the whole configuration
+//
+// The snapshot is taken without locking and is not affected by later calls to
+// Set or Load, so several reads from it are always consistent with each other.
+//
+// Returns:
+// - *Snapshot: the current configuration snapshot
+func (c *Config) Snapshot() *Snapshot {
+	if s := c.data.Load(); s != nil {
+		return s
+	}
+
+	return emptySnapshot
+}
+
+// decrypt Decrypts the encrypted values of the loaded sources
+//
+// The maps holding encrypted values are replaced by decrypted copies, the maps
+// returned by the parsers are never modified.
+//
+// Parameters:
+// - loaded: []map[string]string - The values returned by the parsers
+//
+// Returns:
+// - map[string]bool: the keys whose value has been decrypted
+// - error: error if a value cannot be decrypted
+func (c *Config) decrypt(loaded []map[string]string) (map[string]bool, error) {
+	var key []byte
+	decrypted := map[string]bool{}
 
-	if value, ok := c.data[key]; ok {
-		return value
+	for i, data := range loaded {
+		plaintexts := map[string]string{}
+
+		for name, value := range data {
+			if !secret.IsEncrypted(value) {
+				continue
+			}
+
+			if key == nil {
+				if c.keys == nil {
+					return nil, fmt.Errorf("failed to decrypt %q: no key provider", name)
+				}
+
+				var err error
+				if key, err = c.keys.Key(); err != nil {
+					return nil, fmt.Errorf("failed to get decryption key: %w", err)
+				}
+			}
+
+			plaintext, err := secret.Decrypt(key, value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt %q: %w", name, err)
+			}
+
+			plaintexts[name] = plaintext
+			decrypted[name] = true
+		}
+
+		if len(plaintexts) > 0 {
+			loaded[i] = maps.Clone(data)
+			maps.Copy(loaded[i], plaintexts)
+		}
 	}
 
-	return defaultValue
+	return decrypted, nil
 }
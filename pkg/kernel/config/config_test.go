@@ -1,12 +1,15 @@
 package config_test
 
 import (
+	"encoding/hex"
 	"os"
 	"strings"
 	"testing"
 
 	"github.com/kistunium/sdk/pkg/kernel/config"
 	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/kistunium/sdk/pkg/kernel/config/secret"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -144,36 +147,21 @@ json:
           yaml: yaml_value
 `
 
-	jsonFile, err := os.CreateTemp("", "test_config_*.json")
-	assert.NoError(t, err)
-	defer os.Remove(jsonFile.Name())
-	_, err = jsonFile.Write([]byte(strings.TrimSpace(jsonContent)))
-	assert.NoError(t, err)
-	err = jsonFile.Close()
-	assert.NoError(t, err)
+	jsonFile := fs.TempFileFor(t, "", "test_config_*.json")
+	assert.NoError(t, jsonFile.WriteAtomic([]byte(strings.TrimSpace(jsonContent)), 0o600))
 
-	yamlFile, err := os.CreateTemp("", "test_config_*.yaml")
-	assert.NoError(t, err)
-	defer os.Remove(yamlFile.Name())
-	_, err = yamlFile.Write([]byte(strings.TrimSpace(yamlContent)))
-	assert.NoError(t, err)
-	err = yamlFile.Close()
-	assert.NoError(t, err)
+	yamlFile := fs.TempFileFor(t, "", "test_config_*.yaml")
+	assert.NoError(t, yamlFile.WriteAtomic([]byte(strings.TrimSpace(yamlContent)), 0o600))
 
-	xmlFile, err := os.CreateTemp("", "test_config_*.xml")
-	assert.NoError(t, err)
-	defer os.Remove(xmlFile.Name())
-	_, err = xmlFile.Write([]byte(strings.TrimSpace(xmlContent)))
-	assert.NoError(t, err)
-	err = xmlFile.Close()
-	assert.NoError(t, err)
+	xmlFile := fs.TempFileFor(t, "", "test_config_*.xml")
+	assert.NoError(t, xmlFile.WriteAtomic([]byte(strings.TrimSpace(xmlContent)), 0o600))
 
 	c := config.New(
 		&parser.ENV{},
 		&parser.ARGS{},
-		&parser.XML{Path: xmlFile.Name()},
-		&parser.JSON{Path: jsonFile.Name()},
-		&parser.YAML{Path: yamlFile.Name()},
+		&parser.XML{Path: xmlFile.String()},
+		&parser.JSON{Path: jsonFile.String()},
+		&parser.YAML{Path: yamlFile.String()},
 	)
 
 	assert.Nil(t, c.Load())
@@ -197,3 +185,53 @@ json:
 	// JSON values should be overridden
 	assert.Equal(t, "yaml_value", c.Get("json.value.to.override.by.yaml", nil))
 }
+
+func TestLoadDecryptsValues(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	t.Setenv("CONFIG_KEY", hex.EncodeToString(key))
+
+	encrypted, err := secret.Encrypt(key, "s3cr3t")
+	assert.NoError(t, err)
+
+	source := staticParser{"db.host": "localhost", "db.pass": encrypted}
+
+	c := config.New(source)
+	assert.Error(t, c.Load())
+
+	c.SetKeyProvider(&secret.EnvKey{Name: "CONFIG_KEY"})
+	assert.NoError(t, c.Load())
+	assert.Equal(t, "s3cr3t", c.Get("db.pass", nil))
+	assert.Equal(t, "localhost", c.Get("db.host", nil))
+	assert.Equal(t, encrypted, source["db.pass"])
+
+	changed := config.New(staticParser{"db.host": "localhost", "db.pass": "other"})
+	assert.NoError(t, changed.Load())
+
+	changes := config.Diff(c, changed)
+	assert.Equal(t, config.Redacted, changes[0].Old)
+	assert.True(t, changes[0].Secret)
+
+	c.SetKeyProvider(&secret.PassphraseKey{Passphrase: "wrong", Salt: "salt", Iterations: 1})
+	assert.Error(t, c.Load())
+	assert.Equal(t, "s3cr3t", c.Get("db.pass", nil))
+}
+
+func TestReloadRemovesDeletedKeys(t *testing.T) {
+	source := &mutableParser{data: map[string]string{"db.host": "a", "db.port": "5432", "legacy": "1"}}
+	c := config.New(source)
+	c.SetDefault("db.port", "1")
+	assert.NoError(t, c.Load())
+	c.Set("workers", "8")
+
+	source.data = map[string]string{"db.host": "b"}
+	assert.NoError(t, c.Load())
+
+	snapshot := c.Snapshot()
+	assert.Equal(t, []string{"db.host", "db.port", "workers"}, snapshot.Keys())
+	assert.Equal(t, "b", snapshot.Get("db.host", nil))
+	assert.Equal(t, "1", snapshot.Get("db.port", nil))
+	assert.Equal(t, config.SourceDefault, snapshot.Source("db.port"))
+	assert.Equal(t, "8", snapshot.Get("workers", nil))
+	assert.Equal(t, "set", snapshot.Source("workers"))
+	assert.Equal(t, []string{"db.host", "db.port", "legacy"}, c.History()[len(c.History())-1].Changed)
+}
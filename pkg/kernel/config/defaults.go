@@ -0,0 +1,215 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+)
+
+// SourceDefault is the provenance of the values set by SetDefault or Declare.
+const SourceDefault = "default"
+
+// SetDefault sets the default value of a key
+//
+// The default is visible to every accessor, with the source "default", until a
+// parser or Set provides a value for the key. Like the values of the parsers, it
+// is stored in its string form, e.g. "8080" for 8080, and the key is normalized
+// with normalize.Key. A nil value removes the default. It is declared in the
+// schema returned by Schema. Defaults are not recorded in the history.
+//
+// Parameters:
+// - key: string - The configuration key
+// - value: any - The default value, nil to remove it
+func (c *Config) SetDefault(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key = normalize.Key(key)
+	spec := c.specs[key]
+	spec.Key = key
+	spec.Default = ""
+	if value != nil {
+		spec.Default = fmt.Sprint(value)
+	}
+
+	c.declare([]Spec{spec})
+}
+
+// Declare registers key declarations
+//
+// Declared keys are listed by Schema, and the ones with a Default value get it
+// as with SetDefault. A later declaration of a key replaces the earlier one,
+// including its default, which is removed when the new Default is empty.
+//
+// Parameters:
+// - specs: ...Spec - The key declarations
+func (c *Config) Declare(specs ...Spec) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.declare(specs)
+}
+
+// Schema returns the keys declared with Declare or SetDefault
+//
+// Returns:
+// - *Schema: the declarations sorted by key
+func (c *Config) Schema() *Schema {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	schema := &Schema{}
+	for _, key := range slices.Sorted(maps.Keys(c.specs)) {
+		schema.Keys = append(schema.Keys, c.specs[key])
+	}
+
+	return schema
+}
+
+// declare Registers specs and applies their defaults to the keys without a
+// value from another source, c.mu must be held
+//
+// The snapshot is stored without recording a revision, so that declaring keys
+// does not fill the history.
+func (c *Config) declare(specs []Spec) {
+	if c.specs == nil {
+		c.specs = map[string]Spec{}
+	}
+
+	if c.defaults == nil {
+		c.defaults = map[string]any{}
+	}
+
+	next := c.Snapshot().clone()
+	for _, spec := range specs {
+		spec.Key = normalize.Key(spec.Key)
+		c.specs[spec.Key] = spec
+
+		source, ok := next.sources[spec.Key]
+		if spec.Default == "" {
+			delete(c.defaults, spec.Key)
+			if ok && source == SourceDefault {
+				delete(next.data, spec.Key)
+				delete(next.sources, spec.Key)
+			}
+			continue
+		}
+
+		c.defaults[spec.Key] = spec.Default
+		if ok && source != SourceDefault {
+			continue
+		}
+
+		next.data[spec.Key] = spec.Default
+		next.sources[spec.Key] = SourceDefault
+	}
+
+	c.data.Store(next)
+}
+
+// SchemaOf builds key declarations from the fields of a struct
+//
+// Every exported field is a key named after its "config" tag, or after the
+// field name normalized with normalize.Key. Nested structs add a key segment.
+// The tags "default", "description", "required" ("true") and "enum" (comma
+// separated) fill the Spec, and the type is derived from the Go type:
+//
+//	type Server struct {
+//		Port    int           `config:"port" default:"8080" description:"Listening port"`
+//		Timeout time.Duration `default:"5s"`
+//	}
+//
+// Parameters:
+// - v: any - A struct or a pointer to a struct
+//
+// Returns:
+// - *Schema: the declarations of the fields
+// - error: error if v is not a struct
+func SchemaOf(v any) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, errors.New("failed to build schema: not a struct")
+	}
+
+	schema := &Schema{}
+	fields(t, "", schema)
+
+	return schema, nil
+}
+
+// fields Appends the declarations of the fields of a struct type
+func fields(t reflect.Type, prefix string, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, ok := field.Tag.Lookup("config")
+		if name == "-" {
+			continue
+		}
+		if !ok || name == "" {
+			name = field.Name
+		}
+
+		key := normalize.Key(name)
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		if ft.Kind() == reflect.Struct && ft != reflect.TypeOf(time.Time{}) {
+			fields(ft, key, schema)
+			continue
+		}
+
+		spec := Spec{
+			Key:         key,
+			Type:        specType(ft),
+			Default:     field.Tag.Get("default"),
+			Description: field.Tag.Get("description"),
+			Required:    field.Tag.Get("required") == "true",
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			spec.Enum = strings.Split(enum, ",")
+		}
+
+		schema.Keys = append(schema.Keys, spec)
+	}
+}
+
+// specType Returns the Spec type matching a Go type
+func specType(t reflect.Type) string {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return "duration"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "int"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.String:
+		return "string"
+	default:
+		return ""
+	}
+}
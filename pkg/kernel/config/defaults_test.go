@@ -0,0 +1,112 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type serverConfig struct {
+	Port     int           `config:"port" default:"8080" description:"Listening port"`
+	Timeout  time.Duration `default:"5s" description:"Request timeout"`
+	Mode     string        `enum:"dev,prod" default:"dev" required:"true"`
+	Database struct {
+		Host string `default:"localhost" description:"Database | host"`
+	} `config:"db"`
+	Ignored string `config:"-"`
+	private string
+}
+
+func TestSetDefault(t *testing.T) {
+	c := config.New(staticParser{"port": "9090"})
+	c.SetDefault("port", 8080)
+	c.SetDefault("host", "localhost")
+
+	assert.Equal(t, "8080", c.Get("port", 0))
+	assert.Equal(t, "default", c.Snapshot().Source("port"))
+	assert.Empty(t, c.History())
+
+	assert.NoError(t, c.Load())
+	assert.Equal(t, "9090", c.Get("port", 0))
+	assert.Equal(t, "static", c.Snapshot().Source("port"))
+	assert.Equal(t, "localhost", c.Get("host", "other"))
+	assert.Equal(t, "default", c.Snapshot().Source("host"))
+
+	// A default never overrides a loaded or set value.
+	c.Set("host", "example.com")
+	c.SetDefault("host", "127.0.0.1")
+	c.SetDefault("port", 1)
+	assert.Equal(t, "example.com", c.Get("host", nil))
+	assert.Equal(t, "9090", c.Get("port", nil))
+
+	schema := c.Schema()
+	assert.Equal(t, []config.Spec{{Key: "host", Default: "127.0.0.1"}, {Key: "port", Default: "1"}}, schema.Keys)
+}
+
+func TestSetDefaultNormalizesKeys(t *testing.T) {
+	c := config.New(staticParser{"db.port": "5432"})
+	c.SetDefault("DB.Port", 8080)
+	c.SetDefault("DB_Host", "localhost")
+
+	assert.Equal(t, "8080", c.Get("db.port", nil))
+	assert.NoError(t, c.Load())
+	assert.Equal(t, "5432", c.Get("db.port", nil))
+	assert.Equal(t, "localhost", c.Get("db.host", nil))
+	assert.Equal(t, []config.Spec{{Key: "db.host", Default: "localhost"}, {Key: "db.port", Default: "8080"}}, c.Schema().Keys)
+
+	// A nil default removes the default instead of storing "<nil>".
+	c.SetDefault("db.host", nil)
+	assert.False(t, c.Snapshot().Has("db.host"))
+	assert.NoError(t, c.Load())
+	assert.False(t, c.Snapshot().Has("db.host"))
+}
+
+func TestDeclareClearsDefault(t *testing.T) {
+	c := config.New()
+	c.Declare(config.Spec{Key: "port", Default: "8080"})
+	assert.Equal(t, "8080", c.Get("port", nil))
+
+	c.Declare(config.Spec{Key: "port", Type: "int"})
+	assert.False(t, c.Snapshot().Has("port"))
+	assert.NoError(t, c.Load())
+	assert.False(t, c.Snapshot().Has("port"))
+	assert.Equal(t, []config.Spec{{Key: "port", Type: "int"}}, c.Schema().Keys)
+
+	// A value from another source is kept.
+	c.Declare(config.Spec{Key: "port", Default: "8080"})
+	c.Set("port", "9090")
+	c.Declare(config.Spec{Key: "port"})
+	assert.Equal(t, "9090", c.Get("port", nil))
+}
+
+func TestDeclareSchemaOf(t *testing.T) {
+	schema, err := config.SchemaOf(&serverConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []config.Spec{
+		{Key: "port", Type: "int", Default: "8080", Description: "Listening port"},
+		{Key: "timeout", Type: "duration", Default: "5s", Description: "Request timeout"},
+		{Key: "mode", Type: "string", Default: "dev", Required: true, Enum: []string{"dev", "prod"}},
+		{Key: "db.host", Type: "string", Default: "localhost", Description: "Database | host"},
+	}, schema.Keys)
+
+	_, err = config.SchemaOf(42)
+	assert.Error(t, err)
+
+	c := config.New()
+	c.Declare(schema.Keys...)
+	assert.Equal(t, "5s", c.Get("timeout", nil))
+	assert.Equal(t, "default", c.Snapshot().Source("db.host"))
+	assert.NoError(t, c.Schema().Validate(c))
+
+	var doc strings.Builder
+	assert.NoError(t, c.Schema().Markdown(&doc))
+	assert.Equal(t, "| Key | Type | Default | Required | Description |\n"+
+		"| --- | --- | --- | --- | --- |\n"+
+		"| `db.host` | string | `localhost` |  | Database \\| host |\n"+
+		"| `mode` | string | `dev` | yes | One of `dev`, `prod`. |\n"+
+		"| `port` | int | `8080` |  | Listening port |\n"+
+		"| `timeout` | duration | `5s` |  | Request timeout |\n", doc.String())
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ChangeKind describes how a key differs between two configurations.
+type ChangeKind string
+
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Redacted replaces the value of secret keys when a diff is rendered.
+const Redacted = "[REDACTED]"
+
+// defaultSecretPatterns lists the words identifying a secret value when no
+// patterns are set with Config.SetSecretPatterns.
+var defaultSecretPatterns = []string{"password", "passwd", "secret", "token", "apikey", "credential", "private"}
+
+// Change is a single difference between two configurations.
+type Change struct {
+	Key       string     `json:"key"`
+	Kind      ChangeKind `json:"kind"`
+	Old       any        `json:"old,omitempty"`
+	New       any        `json:"new,omitempty"`
+	OldSource string     `json:"old_source,omitempty"`
+	NewSource string     `json:"new_source,omitempty"`
+	Secret    bool       `json:"secret,omitempty"`
+}
+
+// Changes is the ordered list of differences returned by Diff.
+type Changes []Change
+
+// Diff compares two configurations
+//
+// Both sides can be a Config, a Snapshot or the result of Collect on a parser
+// set. Keys are reported in lexical order. Values of secret keys, and of keys
+// that were encrypted in their source, are compared but replaced by Redacted in
+// the result.
+//
+// Parameters:
+// - a: Viewer - The configuration to compare from
+// - b: Viewer - The configuration to compare to
+//
+// Returns:
+// - Changes: the added, removed and changed keys
+func Diff(a, b Viewer) Changes {
+	from, to := a.Snapshot(), b.Snapshot()
+	changes := Changes{}
+
+	keys := map[string]struct{}{}
+	for key := range from.data {
+		keys[key] = struct{}{}
+	}
+	for key := range to.data {
+		keys[key] = struct{}{}
+	}
+
+	for _, key := range sortedKeys(keys) {
+		oldValue, inFrom := from.data[key]
+		newValue, inTo := to.data[key]
+
+		change := Change{
+			Key:       key,
+			OldSource: from.sources[key],
+			NewSource: to.sources[key],
+			Secret:    from.Secret(key) || to.Secret(key),
+		}
+
+		switch {
+		case !inFrom:
+			change.Kind, change.New = Added, newValue
+		case !inTo:
+			change.Kind, change.Old = Removed, oldValue
+		case !reflect.DeepEqual(oldValue, newValue):
+			change.Kind, change.Old, change.New = Changed, oldValue, newValue
+		default:
+			continue
+		}
+
+		if change.Secret {
+			change.Old, change.New = redact(change.Old), redact(change.New)
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// DefaultSecretPatterns returns the words identifying a secret key by default
+//
+// Returns:
+// - []string: a copy of the default patterns
+func DefaultSecretPatterns() []string {
+	return slices.Clone(defaultSecretPatterns)
+}
+
+// IsSecret reports whether a key holds a secret value
+//
+// Each dotted segment of the key is split into words on underscores, dashes and
+// case changes. A pattern matches a whole word, its plural, or the whole
+// segment, separators and case being ignored: "db.password", "github.access_token",
+// "tls.PrivateKey" and "api_key" are secret, "tokenizer.model" is not.
+//
+// Parameters:
+// - key: string - The configuration key to check
+// - patterns: ...string - The words identifying a secret, DefaultSecretPatterns
+// when empty
+//
+// Returns:
+// - bool: true if any segment of the key matches one of the patterns
+func IsSecret(key string, patterns ...string) bool {
+	if len(patterns) == 0 {
+		patterns = defaultSecretPatterns
+	}
+
+	for _, segment := range strings.Split(key, ".") {
+		words := keyWords(segment)
+		joined := strings.Join(words, "")
+
+		for _, pattern := range patterns {
+			pattern = strings.Join(keyWords(pattern), "")
+			if joined == pattern || slices.ContainsFunc(words, func(word string) bool {
+				return word == pattern || word == pattern+"s"
+			}) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// keyWords splits a key segment into lower case words on separators and case
+// changes, "accessToken" and "access_token" giving "access" and "token".
+func keyWords(segment string) []string {
+	var words []string
+	var word []rune
+
+	runes := []rune(segment)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(word) > 0 {
+			previous := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(previous) || unicode.IsDigit(previous) || (unicode.IsUpper(previous) && nextLower) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+
+		word = append(word, unicode.ToLower(r))
+	}
+
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	return words
+}
+
+// Keys returns the keys affected by the changes
+//
+// Returns:
+// - []string: the changed keys in lexical order
+func (c Changes) Keys() []string {
+	keys := make([]string, len(c))
+	for i, change := range c {
+		keys[i] = change.Key
+	}
+
+	return keys
+}
+
+// Unified renders the changes as a unified diff
+//
+// Removed values are prefixed with "-", added values with "+" and changed
+// values produce both lines. The provenance of each value is appended as a
+// trailing comment.
+//
+// Parameters:
+// - from: string - The label of the original configuration
+// - to: string - The label of the new configuration
+//
+// Returns:
+// - string: the rendered diff, empty if there are no changes
+func (c Changes) Unified(from, to string) string {
+	if len(c) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", from, to)
+
+	for _, change := range c {
+		if change.Kind != Added {
+			fmt.Fprintf(&b, "-%s = %v%s\n", change.Key, change.Old, provenance(change.OldSource))
+		}
+		if change.Kind != Removed {
+			fmt.Fprintf(&b, "+%s = %v%s\n", change.Key, change.New, provenance(change.NewSource))
+		}
+	}
+
+	return b.String()
+}
+
+// JSON renders the changes as an indented JSON array
+//
+// Returns:
+// - []byte: the JSON document
+// - error: error if a value cannot be encoded
+func (c Changes) JSON() ([]byte, error) {
+	return json.MarshalIndent(c, "", "  ")
+}
+
+// redact hides a value, keeping absent values absent.
+func redact(value any) any {
+	if value == nil {
+		return nil
+	}
+
+	return Redacted
+}
+
+// provenance formats the source of a value for the unified output.
+func provenance(source string) string {
+	if source == "" {
+		return ""
+	}
+
+	return "  # " + source
+}
+
+// sortedKeys returns the keys of a set in lexical order.
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
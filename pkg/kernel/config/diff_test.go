@@ -0,0 +1,109 @@
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	staging, err := config.Collect(staticParser{
+		"db.host":     "staging.local",
+		"db.password": "staging",
+		"cache.ttl":   "60",
+		"debug":       "true",
+	})
+	assert.NoError(t, err)
+
+	prod := config.New(staticParser{
+		"db.host":     "prod.local",
+		"db.password": "prod",
+		"cache.ttl":   "60",
+	})
+	assert.NoError(t, prod.Load())
+	prod.Set("workers", "8")
+
+	changes := config.Diff(staging, prod)
+
+	assert.Equal(t, []string{"db.host", "db.password", "debug", "workers"}, changes.Keys())
+	assert.Equal(t, config.Change{
+		Key:       "db.host",
+		Kind:      config.Changed,
+		Old:       "staging.local",
+		New:       "prod.local",
+		OldSource: "static",
+		NewSource: "static",
+	}, changes[0])
+	assert.Equal(t, config.Redacted, changes[1].Old)
+	assert.Equal(t, config.Redacted, changes[1].New)
+	assert.True(t, changes[1].Secret)
+	assert.Equal(t, config.Removed, changes[2].Kind)
+	assert.Equal(t, config.Added, changes[3].Kind)
+	assert.Equal(t, "set", changes[3].NewSource)
+}
+
+func TestDiffUnified(t *testing.T) {
+	a, _ := config.Collect(staticParser{"a": "1", "b": "2"})
+	b, _ := config.Collect(staticParser{"b": "3", "c": "4"})
+
+	expected := "--- staging\n+++ prod\n" +
+		"-a = 1  # static\n" +
+		"-b = 2  # static\n" +
+		"+b = 3  # static\n" +
+		"+c = 4  # static\n"
+
+	assert.Equal(t, expected, config.Diff(a, b).Unified("staging", "prod"))
+	assert.Equal(t, "", config.Diff(a, a).Unified("staging", "prod"))
+}
+
+func TestDiffJSON(t *testing.T) {
+	a, _ := config.Collect(staticParser{"api.token": "old"})
+	b, _ := config.Collect(staticParser{"api.token": "new"})
+
+	content, err := config.Diff(a, b).JSON()
+	assert.NoError(t, err)
+
+	var decoded []map[string]any
+	assert.NoError(t, json.Unmarshal(content, &decoded))
+	assert.Equal(t, []map[string]any{{
+		"key":        "api.token",
+		"kind":       "changed",
+		"old":        config.Redacted,
+		"new":        config.Redacted,
+		"old_source": "static",
+		"new_source": "static",
+		"secret":     true,
+	}}, decoded)
+}
+
+func TestIsSecret(t *testing.T) {
+	assert.True(t, config.IsSecret("db.password"))
+	assert.True(t, config.IsSecret("github.access_token"))
+	assert.True(t, config.IsSecret("TLS.PrivateKey"))
+	assert.True(t, config.IsSecret("stripe.api_key"))
+	assert.True(t, config.IsSecret("smtp.passwords"))
+	assert.False(t, config.IsSecret("db.host"))
+	assert.False(t, config.IsSecret("tokenizer.model"))
+	assert.False(t, config.IsSecret("nlp.max_tokenizers"))
+
+	assert.True(t, config.IsSecret("license.signing_key", "signing_key"))
+	assert.False(t, config.IsSecret("db.password", "signing_key"))
+}
+
+func TestSetSecretPatterns(t *testing.T) {
+	c := config.New(staticParser{"db.password": "hunter2", "license.key": "abc"})
+	assert.NoError(t, c.Load())
+	assert.True(t, c.Snapshot().Secret("db.password"))
+	assert.False(t, c.Snapshot().Secret("license.key"))
+
+	c.SetSecretPatterns("password", "key")
+	assert.True(t, c.Snapshot().Secret("license.key"))
+
+	c.Set("license.key", "def")
+	changes := config.Diff(c.History()[0].Snapshot(), c)
+	assert.Equal(t, config.Redacted, changes[0].New)
+
+	assert.Contains(t, config.DefaultSecretPatterns(), "password")
+}
@@ -0,0 +1,415 @@
+package encode
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats lists the formats supported by Write.
+var Formats = []string{"json", "yaml", "xml", "toml", "env"}
+
+// Write Serializes a flat configuration map in the given format
+//
+// Parameters:
+// - w: io.Writer - the destination
+// - format: string - one of Formats, "yml" is accepted as an alias of "yaml"
+// - data: map[string]string - the flat configuration, as returned by parsers
+//
+// Returns:
+// - error: error if the format is unknown or the data cannot be represented
+func Write(w io.Writer, format string, data map[string]string) error {
+	switch format {
+	case "json":
+		return JSON(w, data)
+	case "yaml", "yml":
+		return YAML(w, data)
+	case "xml":
+		return XML(w, data)
+	case "toml":
+		return TOML(w, data)
+	case "env":
+		return ENV(w, data)
+	default:
+		return fmt.Errorf("unsupported format: %q", format)
+	}
+}
+
+// JSON Serializes a flat configuration map as an indented JSON document
+//
+// Values are written as strings so that parser.JSON reads them back unchanged.
+//
+// Parameters:
+// - w: io.Writer - the destination
+// - data: map[string]string - the flat configuration
+//
+// Returns:
+// - error: error if a key is both a value and a section
+func JSON(w io.Writer, data map[string]string) error {
+	tree, err := normalize.Tree(data)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(tree)
+}
+
+// YAML Serializes a flat configuration map as a YAML document
+//
+// Parameters:
+// - w: io.Writer - the destination
+// - data: map[string]string - the flat configuration
+//
+// Returns:
+// - error: error if a key is both a value and a section
+func YAML(w io.Writer, data map[string]string) error {
+	tree, err := normalize.Tree(data)
+	if err != nil {
+		return err
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(tree); err != nil {
+		return err
+	}
+
+	return encoder.Close()
+}
+
+// XML Serializes a flat configuration map as an XML document
+//
+// The document root is a <config> element. Array items are written as
+// repeated elements named after the array key.
+//
+// Parameters:
+// - w: io.Writer - the destination
+// - data: map[string]string - the flat configuration
+//
+// Returns:
+// - error: error if a key is both a value and a section
+func XML(w io.Writer, data map[string]string) error {
+	tree, err := normalize.Tree(data)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := writeXML(encoder, "config", tree); err != nil {
+		return err
+	}
+
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// TOML Serializes a flat configuration map as a TOML document
+//
+// Values are written as strings, like JSON. Sections become tables and arrays of
+// sections become arrays of tables, other arrays are written inline.
+//
+// Parameters:
+// - w: io.Writer - the destination
+// - data: map[string]string - the flat configuration
+//
+// Returns:
+// - error: error if a key is both a value and a section
+func TOML(w io.Writer, data map[string]string) error {
+	tree, err := normalize.Tree(data)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	writeTOMLTable(&b, nil, tree)
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// ENV Serializes a flat configuration map as KEY=value lines
+//
+// Keys are upper-cased with dots replaced by underscores, the form read back by
+// parser.ENV. Values containing spaces or quotes are double-quoted.
+//
+// Parameters:
+// - w: io.Writer - the destination
+// - data: map[string]string - the flat configuration
+//
+// Returns:
+// - error: error if writing fails
+func ENV(w io.Writer, data map[string]string) error {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", EnvKey(key), EnvValue(data[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnvKey Converts a configuration key into an environment variable name
+//
+// Parameters:
+// - key: string - the dotted configuration key
+//
+// Returns:
+// - string: the variable name, e.g. DB_HOST for db.host
+func EnvKey(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// EnvValue Quotes a value for a KEY=value line when needed
+//
+// Parameters:
+// - value: string - the raw value
+//
+// Returns:
+// - string: the value, quoted if it holds blanks or '#'
+func EnvValue(value string) string {
+	if !strings.ContainsAny(value, " \t#") {
+		return value
+	}
+
+	if strings.Contains(value, `"`) {
+		return "'" + value + "'"
+	}
+
+	return `"` + value + `"`
+}
+
+// writeXML Writes a tree node as an XML element
+//
+// Parameters:
+// - encoder: *xml.Encoder - the destination
+// - name: string - the element name
+// - value: any - a string, a []any or a map[string]any
+//
+// Returns:
+// - error: error if writing fails
+func writeXML(encoder *xml.Encoder, name string, value any) error {
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			if err := writeXML(encoder, name, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]any:
+		start := xml.StartElement{Name: xml.Name{Local: name}}
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			if err := writeXML(encoder, key, v[key]); err != nil {
+				return err
+			}
+		}
+
+		return encoder.EncodeToken(start.End())
+	default:
+		return encoder.EncodeElement(fmt.Sprint(v), xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
+
+// writeTOMLTable Writes the entries of a table, values first, then the tables
+// and arrays of tables nested in it
+//
+// Parameters:
+// - b: *strings.Builder - the destination
+// - path: []string - the keys leading to the table, empty for the root
+// - table: map[string]any - the entries of the table
+func writeTOMLTable(b *strings.Builder, path []string, table map[string]any) {
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var nested []string
+	for _, key := range keys {
+		switch value := table[key].(type) {
+		case map[string]any:
+			nested = append(nested, key)
+			continue
+		case []any:
+			if tomlTables(value) {
+				nested = append(nested, key)
+				continue
+			}
+		}
+
+		b.WriteString(tomlKey(key) + " = ")
+		writeTOMLValue(b, table[key])
+		b.WriteString("\n")
+	}
+
+	for _, key := range nested {
+		child := append(slices.Clone(path), key)
+		header := make([]string, len(child))
+		for i, segment := range child {
+			header[i] = tomlKey(segment)
+		}
+
+		switch value := table[key].(type) {
+		case map[string]any:
+			// Tables holding only tables are implied by the headers of their
+			// children.
+			if tomlValues(value) || len(value) == 0 {
+				writeTOMLHeader(b, "["+strings.Join(header, ".")+"]")
+			}
+			writeTOMLTable(b, child, value)
+		case []any:
+			for _, item := range value {
+				writeTOMLHeader(b, "[["+strings.Join(header, ".")+"]]")
+				writeTOMLTable(b, child, item.(map[string]any))
+			}
+		}
+	}
+}
+
+// writeTOMLHeader Writes a table header, separated from the previous entries
+// by a blank line
+func writeTOMLHeader(b *strings.Builder, header string) {
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(header + "\n")
+}
+
+// writeTOMLValue Writes a string, an inline array or an inline table
+func writeTOMLValue(b *strings.Builder, value any) {
+	switch v := value.(type) {
+	case []any:
+		b.WriteString("[")
+		for i, item := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeTOMLValue(b, item)
+		}
+		b.WriteString("]")
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		b.WriteString("{")
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(tomlKey(key) + " = ")
+			writeTOMLValue(b, v[key])
+		}
+		b.WriteString("}")
+	default:
+		b.WriteString(tomlString(fmt.Sprint(v)))
+	}
+}
+
+// tomlValues Reports whether a table holds values written as key = value
+func tomlValues(table map[string]any) bool {
+	for _, value := range table {
+		switch v := value.(type) {
+		case map[string]any:
+		case []any:
+			if !tomlTables(v) {
+				return true
+			}
+		default:
+			return true
+		}
+	}
+
+	return false
+}
+
+// tomlTables Reports whether an array only holds sections, written as an array
+// of tables
+func tomlTables(items []any) bool {
+	for _, item := range items {
+		if _, ok := item.(map[string]any); !ok {
+			return false
+		}
+	}
+
+	return len(items) > 0
+}
+
+// tomlKey Returns a key as a bare key when possible, quoted otherwise
+func tomlKey(key string) string {
+	if key == "" || strings.IndexFunc(key, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) >= 0 {
+		return tomlString(key)
+	}
+
+	return key
+}
+
+// tomlString Returns a value as a TOML basic string
+func tomlString(value string) string {
+	var b strings.Builder
+
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package encode_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/kistunium/sdk/pkg/kernel/config/encode"
+	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+var data = map[string]string{
+	"app.name":                    "TestApp",
+	"app.version":                 "1.0",
+	"settings.port":               "8080",
+	"settings.features.feature.0": "login",
+	"settings.features.feature.1": "signup",
+	"servers.server.0.name":       "server1",
+	"servers.server.0.ip":         "192.168.1.1",
+	"servers.server.1.name":       "server2",
+	"servers.server.1.ip":         "192.168.1.2",
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	for format, load := range map[string]func(string) config.Parser{
+		"json": func(path string) config.Parser { return &parser.JSON{Path: path} },
+		"yaml": func(path string) config.Parser { return &parser.YAML{Path: path} },
+		"xml":  func(path string) config.Parser { return &parser.XML{Path: path} },
+	} {
+		t.Run(format, func(t *testing.T) {
+			var output bytes.Buffer
+			assert.NoError(t, encode.Write(&output, format, data))
+
+			file := filepath.Join(dir, "config."+format)
+			assert.NoError(t, os.WriteFile(file, output.Bytes(), 0o600))
+
+			loaded, err := load(file).Load()
+			assert.NoError(t, err)
+			assert.Equal(t, data, loaded)
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var output bytes.Buffer
+	assert.NoError(t, encode.JSON(&output, map[string]string{"db.host": "localhost", "db.ports.0": "5432"}))
+	assert.JSONEq(t, `{"db": {"host": "localhost", "ports": ["5432"]}}`, output.String())
+}
+
+func TestENV(t *testing.T) {
+	var output bytes.Buffer
+	assert.NoError(t, encode.ENV(&output, map[string]string{
+		"db.host":    "localhost",
+		"app.banner": "hello world",
+		"app.quote":  `say "hi" #1`,
+	}))
+	assert.Equal(t, "APP_BANNER=\"hello world\"\nAPP_QUOTE='say \"hi\" #1'\nDB_HOST=localhost\n", output.String())
+}
+
+func TestTOML(t *testing.T) {
+	var output bytes.Buffer
+	assert.NoError(t, encode.TOML(&output, data))
+	assert.Equal(t, `[app]
+name = "TestApp"
+version = "1.0"
+
+[[servers.server]]
+ip = "192.168.1.1"
+name = "server1"
+
+[[servers.server]]
+ip = "192.168.1.2"
+name = "server2"
+
+[settings]
+port = "8080"
+
+[settings.features]
+feature = ["login", "signup"]
+`, output.String())
+
+	output.Reset()
+	assert.NoError(t, encode.TOML(&output, map[string]string{"title": "say \"hi\"\n", "my.key": "a\\b", "hosts.web app": "x"}))
+	assert.Equal(t, "title = \"say \\\"hi\\\"\\n\"\n\n[hosts]\n\"web app\" = \"x\"\n\n[my]\nkey = \"a\\\\b\"\n", output.String())
+}
+
+func TestConflictingKeys(t *testing.T) {
+	var output bytes.Buffer
+	assert.Error(t, encode.YAML(&output, map[string]string{"db": "x", "db.host": "localhost"}))
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	var output bytes.Buffer
+	assert.Error(t, encode.Write(&output, "ini", data))
+}
@@ -0,0 +1,458 @@
+package config
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/encode"
+	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+	"gopkg.in/yaml.v3"
+)
+
+// SampleFormats lists the formats supported by Schema.Sample.
+var SampleFormats = []string{"yaml", "json", "jsonc", "xml"}
+
+// durationPattern matches the values accepted by time.ParseDuration.
+const durationPattern = `^[-+]?(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$|^0$`
+
+// Markdown writes the reference document of the schema
+//
+// Every key is listed with its type, default value, whether it is required
+// and its description followed by its allowed values, in the order of the
+// schema.
+//
+// Parameters:
+// - w: io.Writer - The destination of the document
+//
+// Returns:
+// - error: error if the document cannot be written
+func (s *Schema) Markdown(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("| Key | Type | Default | Required | Description |\n")
+	b.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, spec := range s.Keys {
+		required := ""
+		if spec.Required {
+			required = "yes"
+		}
+
+		description := spec.Description
+		if len(spec.Enum) > 0 {
+			values := make([]string, len(spec.Enum))
+			for i, value := range spec.Enum {
+				values[i] = markdownCode(value)
+			}
+			description = strings.TrimSpace(description + " One of " + strings.Join(values, ", ") + ".")
+		}
+
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n",
+			spec.Key, spec.Type, markdownCode(spec.Default), required, markdownCell(description))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Sample writes a sample configuration file holding every key with its default
+//
+// The "yaml", "jsonc" and "xml" samples describe each key in a comment. The
+// "json" sample has no comments so that strict JSON parsers accept it. Values
+// of int, float and bool keys are written unquoted when their default is valid.
+//
+// Parameters:
+// - w: io.Writer - The destination of the sample
+// - format: string - One of SampleFormats, "yml" is accepted as an alias of "yaml"
+//
+// Returns:
+// - error: error if the format is unknown, a key is both a value and a section,
+// or the sample cannot be written
+func (s *Schema) Sample(w io.Writer, format string) error {
+	root, err := s.tree()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	switch format {
+	case "yaml", "yml":
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root.yaml()}}); err != nil {
+			return err
+		}
+		return encoder.Close()
+	case "json", "jsonc":
+		root.json(&b, "", format == "jsonc")
+		b.WriteString("\n")
+	case "xml":
+		b.WriteString(xml.Header)
+		root.name = "config"
+		root.xml(&b, "")
+	default:
+		return fmt.Errorf("unsupported format: %q", format)
+	}
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// EnvExample writes a .env.example file holding every key with its default
+//
+// Each key is written as a KEY=value line in the form read back by parser.ENV,
+// preceded by its description. Keys that parser.ENV cannot produce, such as
+// keys with underscores, are listed in a comment instead.
+//
+// Parameters:
+// - w: io.Writer - The destination of the file
+//
+// Returns:
+// - error: error if the file cannot be written
+func (s *Schema) EnvExample(w io.Writer) error {
+	var b strings.Builder
+
+	for i, spec := range s.Keys {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		for _, line := range spec.comment() {
+			fmt.Fprintf(&b, "# %s\n", line)
+		}
+
+		name := encode.EnvKey(spec.Key)
+		if normalize.Key(name) != spec.Key {
+			fmt.Fprintf(&b, "# %s cannot be set from the environment\n", spec.Key)
+			continue
+		}
+
+		fmt.Fprintf(&b, "%s=%s\n", name, encode.EnvValue(spec.Default))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// JSONSchema writes a JSON Schema (draft 2020-12) describing the keys
+//
+// The schema matches the documents written by Sample in the "json" format and
+// can be used by editors to complete and check configuration files.
+//
+// Parameters:
+// - w: io.Writer - The destination of the schema
+//
+// Returns:
+// - error: error if a key is both a value and a section, or the schema cannot
+// be written
+func (s *Schema) JSONSchema(w io.Writer) error {
+	root, err := s.tree()
+	if err != nil {
+		return err
+	}
+
+	document := root.jsonSchema()
+	document["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(document)
+}
+
+// comment Returns the lines describing the spec
+func (s Spec) comment() []string {
+	var lines []string
+	if s.Description != "" {
+		lines = append(lines, strings.Split(s.Description, "\n")...)
+	}
+	if len(s.Enum) > 0 {
+		lines = append(lines, "One of: "+strings.Join(s.Enum, ", "))
+	}
+	if s.Required {
+		lines = append(lines, "Required.")
+	}
+
+	return lines
+}
+
+// typed Reports whether the default is written as a JSON or YAML scalar of the
+// spec type instead of a string
+func (s Spec) typed() bool {
+	return s.scalar(s.Default)
+}
+
+// scalar Reports whether a value is written as a JSON or YAML scalar of the spec
+// type instead of a string
+func (s Spec) scalar(value string) bool {
+	switch s.Type {
+	case "int", "float":
+		return s.Check(value) == nil && json.Valid([]byte(value))
+	case "bool":
+		return value == "true" || value == "false"
+	default:
+		return false
+	}
+}
+
+// jsonValue Returns a value as a JSON scalar of the spec type, or as a string
+func (s Spec) jsonValue(value string) any {
+	if s.scalar(value) {
+		return json.RawMessage(value)
+	}
+
+	return value
+}
+
+// sampleNode is a section or a key of a generated document.
+type sampleNode struct {
+	name     string
+	spec     *Spec
+	children []*sampleNode
+}
+
+// tree Builds the document tree of the schema, in the order of the keys
+func (s *Schema) tree() (*sampleNode, error) {
+	root := &sampleNode{}
+
+	for i := range s.Keys {
+		node := root
+		segments := strings.Split(s.Keys[i].Key, ".")
+		for j, segment := range segments {
+			if node.spec != nil {
+				return nil, fmt.Errorf("key %q is both a value and a section", strings.Join(segments[:j], "."))
+			}
+			node = node.child(segment)
+		}
+
+		if node.spec != nil || len(node.children) > 0 {
+			return nil, fmt.Errorf("key %q is both a value and a section", s.Keys[i].Key)
+		}
+		node.spec = &s.Keys[i]
+	}
+
+	return root, nil
+}
+
+// child Returns the child with the given name, created if missing
+func (n *sampleNode) child(name string) *sampleNode {
+	for _, child := range n.children {
+		if child.name == name {
+			return child
+		}
+	}
+
+	child := &sampleNode{name: name}
+	n.children = append(n.children, child)
+
+	return child
+}
+
+// items Returns the children ordered by index when they are the indexes
+// 0..n-1 of an array
+func (n *sampleNode) items() ([]*sampleNode, bool) {
+	if len(n.children) == 0 {
+		return nil, false
+	}
+
+	items := make([]*sampleNode, len(n.children))
+	for _, child := range n.children {
+		index, err := strconv.Atoi(child.name)
+		if err != nil || index < 0 || index >= len(items) || strconv.Itoa(index) != child.name || items[index] != nil {
+			return nil, false
+		}
+		items[index] = child
+	}
+
+	return items, true
+}
+
+// yaml Converts the node into a YAML node
+func (n *sampleNode) yaml() *yaml.Node {
+	if n.spec != nil {
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: n.spec.Default}
+		if n.spec.typed() {
+			node.Tag = map[string]string{"int": "!!int", "float": "!!float", "bool": "!!bool"}[n.spec.Type]
+		}
+		return node
+	}
+
+	if items, ok := n.items(); ok {
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range items {
+			value := item.yaml()
+			value.HeadComment = item.headComment()
+			node.Content = append(node.Content, value)
+		}
+		return node
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, child := range n.children {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: child.name, HeadComment: child.headComment()}
+		node.Content = append(node.Content, key, child.yaml())
+	}
+
+	return node
+}
+
+// headComment Returns the YAML comment of a key
+func (n *sampleNode) headComment() string {
+	if n.spec == nil {
+		return ""
+	}
+
+	return strings.Join(n.spec.comment(), "\n")
+}
+
+// json Writes the node as JSON, with "//" comments when requested
+func (n *sampleNode) json(b *strings.Builder, indent string, comments bool) {
+	if n.spec != nil {
+		if n.spec.typed() {
+			b.WriteString(n.spec.Default)
+		} else {
+			b.WriteString(strconv.Quote(n.spec.Default))
+		}
+		return
+	}
+
+	open, close := "{", "}"
+	children, array := n.items()
+	if array {
+		open, close = "[", "]"
+	} else {
+		children = n.children
+	}
+
+	b.WriteString(open)
+	for i, child := range children {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n")
+
+		if comments && child.spec != nil {
+			for _, line := range child.spec.comment() {
+				fmt.Fprintf(b, "%s  // %s\n", indent, line)
+			}
+		}
+
+		b.WriteString(indent + "  ")
+		if !array {
+			b.WriteString(strconv.Quote(child.name) + ": ")
+		}
+		child.json(b, indent+"  ", comments)
+	}
+	if len(children) > 0 {
+		b.WriteString("\n" + indent)
+	}
+	b.WriteString(close)
+}
+
+// xml Writes the node as an XML element, array items as repeated elements
+func (n *sampleNode) xml(b *strings.Builder, indent string) {
+	if n.spec != nil {
+		for _, line := range n.spec.comment() {
+			fmt.Fprintf(b, "%s<!-- %s -->\n", indent, strings.ReplaceAll(line, "--", "- -"))
+		}
+
+		fmt.Fprintf(b, "%s<%s>", indent, n.name)
+		xml.EscapeText(b, []byte(n.spec.Default))
+		fmt.Fprintf(b, "</%s>\n", n.name)
+		return
+	}
+
+	fmt.Fprintf(b, "%s<%s>\n", indent, n.name)
+	for _, child := range n.children {
+		if items, ok := child.items(); ok {
+			for _, item := range items {
+				item.name = child.name
+				item.xml(b, indent+"  ")
+			}
+			continue
+		}
+
+		child.xml(b, indent+"  ")
+	}
+	fmt.Fprintf(b, "%s</%s>\n", indent, n.name)
+}
+
+// jsonSchema Converts the node into a JSON Schema
+func (n *sampleNode) jsonSchema() map[string]any {
+	if n.spec != nil {
+		schema := map[string]any{}
+		switch n.spec.Type {
+		case "string":
+			schema["type"] = "string"
+		case "int":
+			schema["type"] = "integer"
+		case "float":
+			schema["type"] = "number"
+		case "bool":
+			schema["type"] = "boolean"
+		case "duration":
+			schema["type"] = "string"
+			schema["pattern"] = durationPattern
+		}
+
+		if n.spec.Description != "" {
+			schema["description"] = n.spec.Description
+		}
+		if n.spec.Default != "" {
+			schema["default"] = n.spec.jsonValue(n.spec.Default)
+		}
+		if len(n.spec.Enum) > 0 {
+			enum := make([]any, len(n.spec.Enum))
+			for i, value := range n.spec.Enum {
+				enum[i] = n.spec.jsonValue(value)
+			}
+			schema["enum"] = enum
+		}
+
+		return schema
+	}
+
+	if items, ok := n.items(); ok {
+		prefixItems := make([]any, len(items))
+		for i, item := range items {
+			prefixItems[i] = item.jsonSchema()
+		}
+		return map[string]any{"type": "array", "prefixItems": prefixItems}
+	}
+
+	properties := map[string]any{}
+	required := []string{}
+	for _, child := range n.children {
+		properties[child.name] = child.jsonSchema()
+		if child.spec != nil && child.spec.Required && child.spec.Default == "" {
+			required = append(required, child.name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		slices.Sort(required)
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+// markdownCode Formats a value as inline code, empty values stay empty
+func markdownCode(value string) string {
+	if value == "" {
+		return ""
+	}
+
+	return "`" + markdownCell(value) + "`"
+}
+
+// markdownCell Escapes the characters breaking a table cell
+func markdownCell(value string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(value)
+}
@@ -0,0 +1,197 @@
+package config_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+var generateSchema = &config.Schema{Keys: []config.Spec{
+	{Key: "app.name", Type: "string", Default: "kitsunium", Description: "Application name"},
+	{Key: "app.port", Type: "int", Default: "8080", Description: "Listening port"},
+	{Key: "app.debug", Type: "bool", Default: "false"},
+	{Key: "app.mode", Default: "dev", Enum: []string{"dev", "prod"}, Required: true},
+	{Key: "app.timeout", Type: "duration", Default: "5s", Description: "Request -- timeout"},
+	{Key: "servers.0.host", Default: "a.local"},
+	{Key: "servers.1.host", Default: "b.local"},
+}}
+
+func TestSchemaSampleRoundTrip(t *testing.T) {
+	expected := map[string]string{
+		"app.name":       "kitsunium",
+		"app.port":       "8080",
+		"app.debug":      "false",
+		"app.mode":       "dev",
+		"app.timeout":    "5s",
+		"servers.0.host": "a.local",
+		"servers.1.host": "b.local",
+	}
+
+	for _, format := range config.SampleFormats {
+		t.Run(format, func(t *testing.T) {
+			var sample strings.Builder
+			assert.NoError(t, generateSchema.Sample(&sample, format))
+
+			file := filepath.Join(t.TempDir(), "config."+format)
+			assert.NoError(t, os.WriteFile(file, []byte(sample.String()), 0o600))
+
+			var p config.Parser
+			switch format {
+			case "yaml":
+				p = &parser.YAML{Path: file}
+				assert.Contains(t, sample.String(), "# Listening port\n  port: 8080\n")
+			case "xml":
+				p = &parser.XML{Path: file}
+				assert.Contains(t, sample.String(), "<!-- Request - - timeout -->")
+			default:
+				p = &parser.JSON{Path: file}
+				assert.Equal(t, format == "jsonc", strings.Contains(sample.String(), "// Listening port\n"))
+			}
+
+			config, err := p.Load()
+			assert.NoError(t, err)
+			assert.Equal(t, expected, config)
+		})
+	}
+
+	assert.Error(t, generateSchema.Sample(&strings.Builder{}, "toml"))
+
+	conflict := &config.Schema{Keys: []config.Spec{{Key: "db"}, {Key: "db.host"}}}
+	assert.Error(t, conflict.Sample(&strings.Builder{}, "yaml"))
+}
+
+func TestSchemaEnvExample(t *testing.T) {
+	schema := &config.Schema{Keys: []config.Spec{
+		{Key: "db.host", Default: "localhost", Description: "Database host"},
+		{Key: "app.greeting", Default: "hello world", Required: true},
+		{Key: "log_level", Default: "info"},
+	}}
+
+	var env strings.Builder
+	assert.NoError(t, schema.EnvExample(&env))
+	assert.Equal(t, "# Database host\nDB_HOST=localhost\n\n"+
+		"# Required.\nAPP_GREETING=\"hello world\"\n\n"+
+		"# log_level cannot be set from the environment\n", env.String())
+}
+
+func TestSchemaJSONSchema(t *testing.T) {
+	var document strings.Builder
+	assert.NoError(t, generateSchema.JSONSchema(&document))
+
+	var decoded map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(document.String()), &decoded))
+	assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", decoded["$schema"])
+
+	app := decoded["properties"].(map[string]any)["app"].(map[string]any)
+	// Required keys with a default can be omitted.
+	assert.Nil(t, app["required"])
+
+	port := app["properties"].(map[string]any)["port"].(map[string]any)
+	assert.Equal(t, map[string]any{"type": "integer", "default": float64(8080), "description": "Listening port"}, port)
+
+	servers := decoded["properties"].(map[string]any)["servers"].(map[string]any)
+	assert.Equal(t, "array", servers["type"])
+	assert.Len(t, servers["prefixItems"], 2)
+
+	document.Reset()
+	required := &config.Schema{Keys: []config.Spec{{Key: "token", Required: true}}}
+	assert.NoError(t, required.JSONSchema(&document))
+	assert.Contains(t, document.String(), `"required": [
+    "token"
+  ]`)
+}
+
+func TestSchemaJSONSchemaValidatesSample(t *testing.T) {
+	schema := &config.Schema{Keys: []config.Spec{
+		{Key: "app.port", Type: "int", Default: "8080", Enum: []string{"80", "8080"}},
+		{Key: "app.ratio", Type: "float", Default: "0.5", Enum: []string{"0.5", "1.5"}},
+		{Key: "app.debug", Type: "bool", Default: "false", Enum: []string{"false"}},
+		{Key: "app.mode", Default: "dev", Enum: []string{"dev", "prod"}},
+		{Key: "app.timeout", Type: "duration", Default: "5s"},
+		{Key: "servers.0.host", Default: "a.local", Required: true},
+	}}
+
+	var document, sample strings.Builder
+	assert.NoError(t, schema.JSONSchema(&document))
+	assert.NoError(t, schema.Sample(&sample, "json"))
+
+	var decodedSchema, decodedSample any
+	assert.NoError(t, json.Unmarshal([]byte(document.String()), &decodedSchema))
+	assert.NoError(t, json.Unmarshal([]byte(sample.String()), &decodedSample))
+	assert.Empty(t, validate(decodedSchema.(map[string]any), decodedSample, ""))
+
+	decodedSample.(map[string]any)["app"].(map[string]any)["port"] = float64(81)
+	assert.Equal(t, []string{"app.port: not in enum"}, validate(decodedSchema.(map[string]any), decodedSample, ""))
+}
+
+// validate Checks a decoded JSON document against the subset of JSON Schema
+// written by Schema.JSONSchema and returns the violations
+func validate(schema map[string]any, value any, key string) []string {
+	var violations []string
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return []string{key + ": not an object"}
+		}
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				violations = append(violations, strings.TrimPrefix(key+"."+name.(string), ".")+": missing")
+			}
+		}
+		for name, property := range schema["properties"].(map[string]any) {
+			if v, ok := object[name]; ok {
+				violations = append(violations, validate(property.(map[string]any), v, strings.TrimPrefix(key+"."+name, "."))...)
+			}
+		}
+		return violations
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return []string{key + ": not an array"}
+		}
+		for i, item := range schema["prefixItems"].([]any) {
+			if i < len(array) {
+				violations = append(violations, validate(item.(map[string]any), array[i], fmt.Sprintf("%s.%d", key, i))...)
+			}
+		}
+		return violations
+	case "integer":
+		if n, ok := value.(float64); !ok || n != float64(int64(n)) {
+			return []string{key + ": not an integer"}
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return []string{key + ": not a number"}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{key + ": not a boolean"}
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{key + ": not a string"}
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+			return []string{key + ": does not match the pattern"}
+		}
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return []string{key + ": not in enum"}
+	}
+
+	return nil
+}
@@ -0,0 +1,327 @@
+package config
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/secret"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+)
+
+// defaultHistoryLimit is the number of revisions kept when SetHistory has not
+// been called.
+const defaultHistoryLimit = 16
+
+// Triggers of a Revision.
+const (
+	TriggerLoad     = "load"
+	TriggerReload   = "reload"
+	TriggerSet      = "set"
+	TriggerRollback = "rollback"
+)
+
+// Revision is a configuration snapshot applied to a Config.
+type Revision struct {
+	// Version numbers the revisions of a Config from 1, in application order.
+	Version uint64    `json:"version"`
+	Time    time.Time `json:"time"`
+	// Trigger is the operation that applied the revision: "load", "reload",
+	// "set" or "rollback".
+	Trigger string `json:"trigger"`
+	// Changed lists the keys added, removed or changed by the revision.
+	Changed []string `json:"changed"`
+
+	snapshot *Snapshot
+}
+
+// Snapshot returns the configuration applied by the revision
+//
+// Returns:
+// - *Snapshot: the configuration of the revision
+func (r Revision) Snapshot() *Snapshot {
+	return r.snapshot
+}
+
+// revisionFile is the persisted form of a Revision. The values of secret keys,
+// see Snapshot.Secret, are encrypted with the key of the Config key provider.
+type revisionFile struct {
+	Revision
+	Values  map[string]any    `json:"values"`
+	Sources map[string]string `json:"sources"`
+	Secrets []string          `json:"secrets,omitempty"`
+}
+
+// SetHistory configures the history of applied revisions
+//
+// At most limit revisions are kept, 16 when limit is zero or negative. When dir
+// is not empty, revisions are also written to it, one JSON file each, and the
+// revisions already in it are restored so that the history survives restarts.
+// Values of secret keys, whether encrypted in their source or matching the
+// secret patterns, are persisted encrypted with the key provider. Revisions
+// holding secrets are not persisted, and a warning is logged, when there is no
+// key provider.
+//
+// The history must be configured before the first Load or Set, so that the
+// restored revisions are numbered before the new ones. Numbers restored from
+// JSON are returned as json.Number values to keep them exact.
+//
+// Parameters:
+// - limit: int - The maximum number of revisions kept
+// - dir: string - The directory persisting the history, empty to keep it in memory
+//
+// Returns:
+// - error: error if revisions have already been recorded, or the directory
+// cannot be created or read
+func (c *Config) SetHistory(limit int, dir string) error {
+	c.loading.Lock()
+	defer c.loading.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.loaded || c.version > 0 {
+		return errors.New("failed to configure history: configuration already loaded")
+	}
+
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
+	c.historyLimit = limit
+	c.historyDir = dir
+
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("failed to create history directory: %w", err)
+		}
+
+		restored, err := c.restore(dir)
+		if err != nil {
+			return err
+		}
+
+		slices.SortFunc(restored, func(a, b Revision) int { return cmp.Compare(a.Version, b.Version) })
+		c.history = restored
+
+		if n := len(c.history); n > 0 {
+			c.version = c.history[n-1].Version
+		}
+	}
+
+	c.trim()
+
+	return nil
+}
+
+// History returns the revisions kept, oldest first
+//
+// Returns:
+// - []Revision: the applied revisions
+func (c *Config) History() []Revision {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.history)
+}
+
+// Rollback applies the configuration of a previous revision again
+//
+// The rollback is itself recorded as a new revision. A later reload builds the
+// configuration again from the defaults, the sources and the values set with
+// Set.
+//
+// Parameters:
+// - version: uint64 - The version of the revision to apply
+//
+// Returns:
+// - error: error if the revision is not in the history
+func (c *Config) Rollback(version uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	i := slices.IndexFunc(c.history, func(r Revision) bool { return r.Version == version })
+	if i < 0 {
+		return fmt.Errorf("revision %d is not in the history", version)
+	}
+
+	next := c.history[i].snapshot.clone()
+	c.bind(next)
+	c.publish(next, TriggerRollback)
+
+	return nil
+}
+
+// publish Stores the next snapshot and records it in the history, c.mu must
+// be held
+//
+// Parameters:
+// - next: *Snapshot - The snapshot to apply
+// - trigger: string - The operation applying the snapshot
+func (c *Config) publish(next *Snapshot, trigger string) {
+	previous := c.Snapshot()
+	c.data.Store(next)
+
+	changed := Diff(previous, next).Keys()
+	if len(changed) == 0 {
+		return
+	}
+
+	c.version++
+	revision := Revision{Version: c.version, Time: time.Now(), Trigger: trigger, Changed: changed, snapshot: next}
+	c.history = append(c.history, revision)
+
+	if c.historyDir != "" {
+		if err := c.persist(revision); err != nil {
+			logger := c.logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.Warn("failed to persist configuration revision", "version", revision.Version, "error", err)
+		}
+	}
+
+	c.trim()
+}
+
+// trim Drops the oldest revisions over the limit, c.mu must be held
+func (c *Config) trim() {
+	limit := c.historyLimit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+
+	if len(c.history) <= limit {
+		return
+	}
+
+	evicted := c.history[:len(c.history)-limit]
+	if c.historyDir != "" {
+		for _, revision := range evicted {
+			_ = os.Remove(revisionPath(c.historyDir, revision.Version))
+		}
+	}
+
+	c.history = slices.Clone(c.history[len(evicted):])
+}
+
+// persist Writes a revision to the history directory, c.mu must be held
+func (c *Config) persist(revision Revision) error {
+	snapshot := revision.snapshot
+	file := revisionFile{
+		Revision: revision,
+		Values:   maps.Clone(snapshot.data),
+		Sources:  snapshot.sources,
+	}
+
+	for _, name := range snapshot.Keys() {
+		if snapshot.Secret(name) {
+			file.Secrets = append(file.Secrets, name)
+		}
+	}
+
+	if len(file.Secrets) > 0 {
+		key, err := c.key()
+		if err != nil {
+			return err
+		}
+
+		for _, name := range file.Secrets {
+			if file.Values[name], err = secret.Encrypt(key, fmt.Sprint(snapshot.data[name])); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	return fs.File(revisionPath(c.historyDir, revision.Version)).WriteAtomic(content, 0o600)
+}
+
+// restore Reads the revisions of a history directory, c.mu must be held
+func (c *Config) restore(dir string) ([]Revision, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	var key []byte
+	var revisions []Revision
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if _, err := strconv.ParseUint(name, 10, 64); err != nil {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read revision: %w", err)
+		}
+
+		var file revisionFile
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&file); err != nil {
+			return nil, fmt.Errorf("failed to parse revision %s: %w", entry.Name(), err)
+		}
+
+		snapshot := &Snapshot{data: file.Values, sources: file.Sources, secrets: map[string]bool{}}
+		if snapshot.data == nil {
+			snapshot.data = map[string]any{}
+		}
+		if snapshot.sources == nil {
+			snapshot.sources = map[string]string{}
+		}
+
+		for _, name := range file.Secrets {
+			if key == nil {
+				if key, err = c.key(); err != nil {
+					return nil, fmt.Errorf("failed to restore revision %d: %w", file.Version, err)
+				}
+			}
+
+			value, err := secret.Decrypt(key, fmt.Sprint(snapshot.data[name]))
+			if err != nil {
+				return nil, fmt.Errorf("failed to restore revision %d: %w", file.Version, err)
+			}
+
+			snapshot.data[name] = value
+			snapshot.secrets[name] = true
+		}
+
+		file.Revision.snapshot = snapshot
+		revisions = append(revisions, file.Revision)
+	}
+
+	return revisions, nil
+}
+
+// key Returns the key of the key provider
+func (c *Config) key() ([]byte, error) {
+	if c.keys == nil {
+		return nil, errors.New("no key provider for secret values")
+	}
+
+	return c.keys.Key()
+}
+
+// revisionPath Returns the file of a revision in a history directory
+func revisionPath(dir string, version uint64) string {
+	return filepath.Join(dir, strconv.FormatUint(version, 10)+".json")
+}
@@ -0,0 +1,150 @@
+package config_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/kistunium/sdk/pkg/kernel/config/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+// mutableParser returns the content of its map at each load.
+type mutableParser struct {
+	data map[string]string
+}
+
+func (p *mutableParser) Load() (map[string]string, error) { return p.data, nil }
+func (p *mutableParser) Type() string                     { return "static" }
+
+func TestHistoryAndRollback(t *testing.T) {
+	source := &mutableParser{data: map[string]string{"db.host": "a", "db.port": "5432"}}
+	c := config.New(source)
+	assert.NoError(t, c.SetHistory(3, ""))
+
+	assert.NoError(t, c.Load())
+	source.data = map[string]string{"db.host": "b", "db.port": "5432"}
+	assert.NoError(t, c.Load())
+	assert.NoError(t, c.Load()) // unchanged, not recorded
+	c.Set("db.port", "6543")
+
+	history := c.History()
+	assert.Len(t, history, 3)
+	assert.Equal(t, []uint64{1, 2, 3}, []uint64{history[0].Version, history[1].Version, history[2].Version})
+	assert.Equal(t, []string{config.TriggerLoad, config.TriggerReload, config.TriggerSet},
+		[]string{history[0].Trigger, history[1].Trigger, history[2].Trigger})
+	assert.Equal(t, []string{"db.host", "db.port"}, history[0].Changed)
+	assert.Equal(t, []string{"db.host"}, history[1].Changed)
+	assert.Equal(t, "a", history[0].Snapshot().Get("db.host", nil))
+
+	assert.NoError(t, c.Rollback(1))
+	assert.Equal(t, "a", c.Get("db.host", nil))
+	assert.Equal(t, "5432", c.Get("db.port", nil))
+
+	history = c.History()
+	assert.Len(t, history, 3)
+	assert.Equal(t, uint64(4), history[2].Version)
+	assert.Equal(t, config.TriggerRollback, history[2].Trigger)
+	assert.Equal(t, []string{"db.host", "db.port"}, history[2].Changed)
+
+	assert.ErrorContains(t, c.Rollback(1), "revision 1 is not in the history")
+}
+
+func TestHistoryPersistence(t *testing.T) {
+	dir := t.TempDir()
+	key := make([]byte, secret.KeySize)
+	encrypted, err := secret.Encrypt(key, "s3cr3t")
+	assert.NoError(t, err)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte(hex.EncodeToString(key)), 0o600))
+
+	newConfig := func() *config.Config {
+		c := config.New(staticParser{"db.password": encrypted, "db.host": "a"})
+		c.SetKeyProvider(&secret.FileKey{Path: keyFile})
+		assert.NoError(t, c.SetHistory(2, dir))
+		return c
+	}
+
+	c := newConfig()
+	assert.NoError(t, c.Load())
+	c.Set("db.host", "b")
+	c.Set("db.host", "c")
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	content, err := os.ReadFile(filepath.Join(dir, "2.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "s3cr3t")
+	assert.True(t, strings.Contains(string(content), "ENC[AES256_GCM"))
+
+	restored := newConfig()
+	history := restored.History()
+	assert.Len(t, history, 2)
+	assert.Equal(t, uint64(2), history[0].Version)
+	assert.Equal(t, "s3cr3t", history[0].Snapshot().Get("db.password", nil))
+	assert.True(t, history[0].Snapshot().Secret("db.password"))
+
+	assert.NoError(t, restored.Rollback(2))
+	assert.Equal(t, "b", restored.Get("db.host", nil))
+	assert.Equal(t, uint64(4), restored.History()[1].Version)
+}
+
+func TestHistoryPersistenceSecretPatterns(t *testing.T) {
+	key := make([]byte, secret.KeySize)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte(hex.EncodeToString(key)), 0o600))
+
+	dir := t.TempDir()
+	c := config.New(staticParser{"api.token": "t0k3n", "db.host": "a"})
+	c.SetKeyProvider(&secret.FileKey{Path: keyFile})
+	assert.NoError(t, c.SetHistory(0, dir))
+	assert.NoError(t, c.Load())
+
+	content, err := os.ReadFile(filepath.Join(dir, "1.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "t0k3n")
+
+	restored := config.New()
+	restored.SetKeyProvider(&secret.FileKey{Path: keyFile})
+	assert.NoError(t, restored.SetHistory(0, dir))
+	assert.Equal(t, "t0k3n", restored.History()[0].Snapshot().Get("api.token", nil))
+
+	// Without a key provider, revisions holding secrets are not written.
+	logger := &recordingLogger{}
+	dir = t.TempDir()
+	c = config.New(staticParser{"api.token": "t0k3n"})
+	c.SetLogger(logger)
+	assert.NoError(t, c.SetHistory(0, dir))
+	assert.NoError(t, c.Load())
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+	assert.Len(t, c.History(), 1)
+	assert.Equal(t, "failed to persist configuration revision", logger.warnings[0][0])
+}
+
+func TestSetHistoryAfterLoad(t *testing.T) {
+	c := config.New(staticParser{"db.host": "a"})
+	assert.NoError(t, c.Load())
+
+	assert.ErrorContains(t, c.SetHistory(0, t.TempDir()), "already loaded")
+}
+
+func TestHistoryPersistenceNumbers(t *testing.T) {
+	dir := t.TempDir()
+	c := config.New()
+	assert.NoError(t, c.SetHistory(0, dir))
+	c.Set("id", uint64(9007199254740993))
+
+	restored := config.New()
+	assert.NoError(t, restored.SetHistory(0, dir))
+	assert.Equal(t, json.Number("9007199254740993"), restored.History()[0].Snapshot().Get("id", nil))
+}
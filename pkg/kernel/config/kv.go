@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+)
+
+const (
+	defaultKVTimeout = 10 * time.Second
+	defaultKVBackoff = time.Second
+)
+
+// KVPair is a single entry read from a key-value store.
+type KVPair struct {
+	Key   string
+	Value []byte
+}
+
+// KVStore is a remote key-value store holding configuration entries.
+type KVStore interface {
+	// List returns every entry under the prefix together with the store index
+	// they were read at.
+	List(ctx context.Context, prefix string) ([]KVPair, uint64, error)
+	// Wait blocks until an entry under the prefix changes after the given index
+	// and returns the new index.
+	Wait(ctx context.Context, prefix string, index uint64) (uint64, error)
+}
+
+// KVSource is a configuration parser reading a key prefix of a KVStore.
+//
+// Store keys are mapped onto dotted configuration keys by stripping the prefix
+// and replacing "/" separators with dots, so "app/db/host" read with the
+// prefix "app/" becomes "db.host". The prefix ends at a "/" boundary: "app"
+// reads "app/db/host" but not "application/x".
+type KVSource struct {
+	// Store is the key-value store to read from.
+	Store KVStore
+	// Prefix is the part of the store keys to read and strip.
+	Prefix string
+	// Timeout bounds a single List call. Defaults to 10 seconds.
+	Timeout time.Duration
+
+	mu    sync.Mutex
+	index uint64
+}
+
+// Type returns the type of the parser.
+func (s *KVSource) Type() string {
+	return "kv"
+}
+
+// Load reads every entry under the prefix
+//
+// Returns:
+// - map[string]string: normalized configuration map
+// - error: error if the store cannot be read
+func (s *KVSource) Load() (map[string]string, error) {
+	pairs, err := s.list(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	config := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		rest, ok := strings.CutPrefix(pair.Key, s.Prefix)
+		if !ok || s.Prefix != "" && !strings.HasSuffix(s.Prefix, "/") && rest != "" && rest[0] != '/' {
+			continue
+		}
+
+		key := strings.Trim(rest, "/")
+		if key == "" || strings.HasSuffix(pair.Key, "/") {
+			continue
+		}
+
+		config[normalize.Key(strings.ReplaceAll(key, "/", "."))] = normalize.Value(string(pair.Value))
+	}
+
+	return config, nil
+}
+
+// Watch waits for changes under the prefix until the context is done
+//
+// Failed waits are retried after a short delay, so a temporarily unavailable
+// store does not stop the watch. When the source has not been loaded yet, the
+// current index is read first so that the first wait does not return at once.
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the watch
+// - changed: func() - called each time an entry under the prefix changes
+//
+// Returns:
+// - error: the context error once watching stops
+func (s *KVSource) Watch(ctx context.Context, changed func()) error {
+	for {
+		s.mu.Lock()
+		index := s.index
+		s.mu.Unlock()
+
+		if index == 0 {
+			if _, err := s.list(ctx); err != nil {
+				if err := kvBackoff(ctx); err != nil {
+					return err
+				}
+				continue
+			}
+
+			s.mu.Lock()
+			index = s.index
+			s.mu.Unlock()
+		}
+
+		next, err := s.Store.Wait(ctx, s.Prefix, index)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err != nil {
+			if err := kvBackoff(ctx); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s.advance(next)
+
+		if next != index {
+			changed()
+		}
+	}
+}
+
+// list Reads every entry under the prefix and records the index of the store
+//
+// Parameters:
+// - ctx: context.Context - cancels the request, bounded by Timeout
+//
+// Returns:
+// - []KVPair: the entries under the prefix
+// - error: error if the store cannot be read
+func (s *KVSource) list(ctx context.Context) ([]KVPair, error) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultKVTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	pairs, index, err := s.Store.List(ctx, s.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %q: %w", s.Prefix, err)
+	}
+
+	s.advance(index)
+
+	return pairs, nil
+}
+
+// advance Records the index of the store, keeping the latest one when Load and
+// Watch race
+func (s *KVSource) advance(index uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.index = max(s.index, index)
+}
+
+// kvBackoff Waits before retrying a failed request
+//
+// Returns:
+// - error: the context error if the context is done first
+func kvBackoff(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(defaultKVBackoff):
+		return nil
+	}
+}
@@ -0,0 +1,163 @@
+package kv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+)
+
+const defaultConsulWait = 5 * time.Minute
+
+// Consul is a config.KVStore backed by the Consul KV HTTP API.
+//
+// Changes are detected with Consul blocking queries on the prefix.
+type Consul struct {
+	// Address is the base URL of the Consul agent, e.g. http://127.0.0.1:8500.
+	Address string
+	// Token is the ACL token sent with every request.
+	Token string
+	// Datacenter selects the datacenter to query, the agent's one when empty.
+	Datacenter string
+	// WaitTime bounds a single blocking query. Defaults to 5 minutes.
+	WaitTime time.Duration
+	// Client replaces the default HTTP client.
+	Client *http.Client
+}
+
+// consulPair is an entry of the Consul KV API answer.
+type consulPair struct {
+	Key   string `json:"Key"`
+	Value []byte `json:"Value"`
+}
+
+// List returns every entry under the prefix
+//
+// Parameters:
+// - ctx: context.Context - cancels the request
+// - prefix: string - the key prefix to read
+//
+// Returns:
+// - []config.KVPair: the entries under the prefix
+// - uint64: the Consul index of the answer
+// - error: error if the request fails
+func (c *Consul) List(ctx context.Context, prefix string) ([]config.KVPair, uint64, error) {
+	res, err := c.get(ctx, prefix, url.Values{})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	index := consulIndex(res)
+	if res.StatusCode == http.StatusNotFound {
+		return []config.KVPair{}, index, nil
+	}
+
+	var pairs []consulPair
+	if err := json.NewDecoder(res.Body).Decode(&pairs); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode Consul answer: %w", err)
+	}
+
+	result := make([]config.KVPair, len(pairs))
+	for i, pair := range pairs {
+		result[i] = config.KVPair{Key: pair.Key, Value: pair.Value}
+	}
+
+	return result, index, nil
+}
+
+// Wait blocks until an entry under the prefix changes after index
+//
+// Parameters:
+// - ctx: context.Context - cancels the blocking query
+// - prefix: string - the key prefix to watch
+// - index: uint64 - the Consul index of the last known state
+//
+// Returns:
+// - uint64: the new Consul index, equal to index when the wait timed out
+// - error: error if the request fails
+func (c *Consul) Wait(ctx context.Context, prefix string, index uint64) (uint64, error) {
+	wait := c.WaitTime
+	if wait <= 0 {
+		wait = defaultConsulWait
+	}
+
+	res, err := c.get(ctx, prefix, url.Values{
+		"index": {strconv.FormatUint(index, 10)},
+		"wait":  {wait.String()},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	next := consulIndex(res)
+
+	// Consul requires resetting the index when it goes backwards.
+	if next < index {
+		return 0, nil
+	}
+
+	return next, nil
+}
+
+// get Sends a recursive read request on the prefix
+//
+// Parameters:
+// - ctx: context.Context - cancels the request
+// - prefix: string - the key prefix to read
+// - query: url.Values - additional query parameters
+//
+// Returns:
+// - *http.Response: the answer, with a 200 or 404 status
+// - error: error if the request fails or the status is unexpected
+func (c *Consul) get(ctx context.Context, prefix string, query url.Values) (*http.Response, error) {
+	query.Set("recurse", "true")
+	if c.Datacenter != "" {
+		query.Set("dc", c.Datacenter)
+	}
+
+	endpoint := strings.TrimSuffix(c.Address, "/") + "/v1/kv/" + strings.TrimPrefix(prefix, "/") + "?" + query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Token != "" {
+		req.Header.Set("X-Consul-Token", c.Token)
+	}
+
+	res, err := client(c.Client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Consul: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected Consul status: %s", res.Status)
+	}
+
+	return res, nil
+}
+
+// consulIndex extracts the X-Consul-Index header of an answer.
+func consulIndex(res *http.Response) uint64 {
+	index, _ := strconv.ParseUint(res.Header.Get("X-Consul-Index"), 10, 64)
+	return index
+}
+
+// client returns the given HTTP client or the default one.
+func client(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+
+	return http.DefaultClient
+}
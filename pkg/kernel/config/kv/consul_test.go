@@ -0,0 +1,234 @@
+package kv_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/kistunium/sdk/pkg/kernel/config/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConsul is a minimal in-memory Consul KV API supporting blocking queries.
+type fakeConsul struct {
+	mu      sync.Mutex
+	index   uint64
+	data    map[string]string
+	changed chan struct{}
+}
+
+func newFakeConsul(data map[string]string) *fakeConsul {
+	return &fakeConsul{index: 1, data: data, changed: make(chan struct{})}
+}
+
+func (f *fakeConsul) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.data[key] = value
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) remove(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.data, key)
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Consul-Token") != "secret" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+
+	if index, err := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); err == nil {
+		f.mu.Lock()
+		current, changed := f.index, f.changed
+		f.mu.Unlock()
+
+		if index >= current {
+			select {
+			case <-changed:
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	pairs := []map[string]any{}
+	for key, value := range f.data {
+		if strings.HasPrefix(key, prefix) {
+			pairs = append(pairs, map[string]any{"Key": key, "Value": []byte(value)})
+		}
+	}
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+	if len(pairs) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(pairs)
+}
+
+func TestConsulLoad(t *testing.T) {
+	server := httptest.NewServer(newFakeConsul(map[string]string{
+		"app/db/host":   "localhost",
+		"app/db/port":   "5432",
+		"app/":          "",
+		"other/db/host": "remote",
+	}))
+	defer server.Close()
+
+	source := &config.KVSource{
+		Store:  &kv.Consul{Address: server.URL, Token: "secret"},
+		Prefix: "app/",
+	}
+
+	data, err := source.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"db.host": "localhost", "db.port": "5432"}, data)
+}
+
+func TestConsulLoadPrefixBoundary(t *testing.T) {
+	server := httptest.NewServer(newFakeConsul(map[string]string{
+		"app/db/host":   "localhost",
+		"app":           "root",
+		"application/x": "other",
+	}))
+	defer server.Close()
+
+	source := &config.KVSource{Store: &kv.Consul{Address: server.URL, Token: "secret"}, Prefix: "app"}
+
+	data, err := source.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"db.host": "localhost"}, data)
+}
+
+// staleStore returns an index older than the one listed from its first Wait.
+type staleStore struct {
+	source  *config.KVSource
+	waited  []uint64
+	stopped context.CancelFunc
+}
+
+func (s *staleStore) List(context.Context, string) ([]config.KVPair, uint64, error) {
+	return nil, 10, nil
+}
+
+func (s *staleStore) Wait(_ context.Context, _ string, index uint64) (uint64, error) {
+	s.waited = append(s.waited, index)
+	if len(s.waited) == 1 {
+		// Load records a newer index while the wait is pending.
+		_, err := s.source.Load()
+		return 7, err
+	}
+
+	s.stopped()
+	return index, nil
+}
+
+func TestKVSourceWatchKeepsLatestIndex(t *testing.T) {
+	store := &staleStore{}
+	store.source = &config.KVSource{Store: store}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	store.stopped = cancel
+
+	assert.ErrorIs(t, store.source.Watch(ctx, func() {}), context.Canceled)
+	assert.Equal(t, []uint64{10, 10}, store.waited)
+}
+
+func TestConsulLoadEmptyPrefix(t *testing.T) {
+	server := httptest.NewServer(newFakeConsul(map[string]string{}))
+	defer server.Close()
+
+	pairs, index, err := (&kv.Consul{Address: server.URL, Token: "secret"}).List(context.Background(), "missing/")
+	assert.NoError(t, err)
+	assert.Empty(t, pairs)
+	assert.Equal(t, uint64(1), index)
+}
+
+func TestConsulLoadForbidden(t *testing.T) {
+	server := httptest.NewServer(newFakeConsul(map[string]string{}))
+	defer server.Close()
+
+	_, err := (&config.KVSource{Store: &kv.Consul{Address: server.URL}, Prefix: "app/"}).Load()
+	assert.Error(t, err)
+}
+
+func TestConsulWatch(t *testing.T) {
+	fake := newFakeConsul(map[string]string{"app/db/host": "localhost"})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	c := config.New(&config.KVSource{
+		Store:  &kv.Consul{Address: server.URL, Token: "secret", WaitTime: time.Second},
+		Prefix: "app/",
+	})
+	assert.NoError(t, c.Load())
+	assert.Equal(t, "localhost", c.Get("db.host", nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Watch(ctx) }()
+
+	fake.put("app/db/host", "remote")
+
+	assert.Eventually(t, func() bool {
+		return c.Get("db.host", nil) == "remote"
+	}, 2*time.Second, 10*time.Millisecond)
+
+	fake.remove("app/db/host")
+
+	assert.Eventually(t, func() bool {
+		return !c.Snapshot().Has("db.host")
+	}, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestConsulWatchBeforeLoad(t *testing.T) {
+	fake := newFakeConsul(map[string]string{"app/db/host": "localhost"})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	source := &config.KVSource{
+		Store:  &kv.Consul{Address: server.URL, Token: "secret", WaitTime: time.Second},
+		Prefix: "app/",
+	}
+
+	var changes atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- source.Watch(ctx, func() { changes.Add(1) }) }()
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Zero(t, changes.Load())
+
+	fake.put("app/db/host", "remote")
+	assert.Eventually(t, func() bool { return changes.Load() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,187 @@
+package kv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+)
+
+// Etcd is a config.KVStore backed by the etcd v3 JSON gateway.
+//
+// Changes are detected with a watch stream on the prefix range.
+type Etcd struct {
+	// Endpoint is the base URL of the gateway, e.g. http://127.0.0.1:2379.
+	Endpoint string
+	// Token is sent as the Authorization header when not empty.
+	Token string
+	// Client replaces the default HTTP client.
+	Client *http.Client
+}
+
+// etcdHeader is the response header of the gateway. Revisions are 64-bit
+// integers encoded as strings.
+type etcdHeader struct {
+	Revision string `json:"revision"`
+}
+
+// etcdRange is the answer of /v3/kv/range.
+type etcdRange struct {
+	Header etcdHeader `json:"header"`
+	Kvs    []struct {
+		Key   []byte `json:"key"`
+		Value []byte `json:"value"`
+	} `json:"kvs"`
+}
+
+// etcdWatch is a message of the /v3/watch stream.
+type etcdWatch struct {
+	Result struct {
+		Header   etcdHeader        `json:"header"`
+		Created  bool              `json:"created"`
+		Canceled bool              `json:"canceled"`
+		Events   []json.RawMessage `json:"events"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// List returns every entry under the prefix
+//
+// Parameters:
+// - ctx: context.Context - cancels the request
+// - prefix: string - the key prefix to read
+//
+// Returns:
+// - []config.KVPair: the entries under the prefix
+// - uint64: the etcd revision of the answer
+// - error: error if the request fails
+func (e *Etcd) List(ctx context.Context, prefix string) ([]config.KVPair, uint64, error) {
+	res, err := e.post(ctx, "/v3/kv/range", map[string]any{
+		"key":       []byte(prefix),
+		"range_end": rangeEnd(prefix),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer res.Body.Close()
+
+	var answer etcdRange
+	if err := json.NewDecoder(res.Body).Decode(&answer); err != nil {
+		return nil, 0, fmt.Errorf("failed to decode etcd answer: %w", err)
+	}
+
+	pairs := make([]config.KVPair, len(answer.Kvs))
+	for i, kv := range answer.Kvs {
+		pairs[i] = config.KVPair{Key: string(kv.Key), Value: kv.Value}
+	}
+
+	revision, _ := strconv.ParseUint(answer.Header.Revision, 10, 64)
+
+	return pairs, revision, nil
+}
+
+// Wait blocks until an entry under the prefix changes after index
+//
+// Parameters:
+// - ctx: context.Context - cancels the watch stream
+// - prefix: string - the key prefix to watch
+// - index: uint64 - the etcd revision of the last known state
+//
+// Returns:
+// - uint64: the revision of the first change
+// - error: error if the stream fails or is canceled by the server
+func (e *Etcd) Wait(ctx context.Context, prefix string, index uint64) (uint64, error) {
+	res, err := e.post(ctx, "/v3/watch", map[string]any{
+		"create_request": map[string]any{
+			"key":            []byte(prefix),
+			"range_end":      rangeEnd(prefix),
+			"start_revision": strconv.FormatUint(index+1, 10),
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	decoder := json.NewDecoder(res.Body)
+	for {
+		var message etcdWatch
+		if err := decoder.Decode(&message); err != nil {
+			return 0, fmt.Errorf("failed to read etcd watch stream: %w", err)
+		}
+
+		if message.Error != nil {
+			return 0, fmt.Errorf("etcd watch failed: %s", message.Error.Message)
+		}
+
+		if message.Result.Canceled {
+			return 0, errors.New("etcd watch canceled")
+		}
+
+		if len(message.Result.Events) > 0 {
+			return strconv.ParseUint(message.Result.Header.Revision, 10, 64)
+		}
+	}
+}
+
+// post Sends a JSON request to the gateway
+//
+// Parameters:
+// - ctx: context.Context - cancels the request
+// - path: string - the gateway endpoint
+// - body: any - the request payload
+//
+// Returns:
+// - *http.Response: the successful answer
+// - error: error if the request fails or the status is not 200
+func (e *Etcd) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(e.Endpoint, "/")+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if e.Token != "" {
+		req.Header.Set("Authorization", e.Token)
+	}
+
+	res, err := client(e.Client).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query etcd: %w", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("unexpected etcd status: %s", res.Status)
+	}
+
+	return res, nil
+}
+
+// rangeEnd returns the smallest key greater than every key with the prefix,
+// following the etcd prefix convention.
+func rangeEnd(prefix string) []byte {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+
+	// Every byte is 0xff, "\x00" means up to the end of the keyspace.
+	return []byte{0}
+}
@@ -0,0 +1,139 @@
+package kv_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/kistunium/sdk/pkg/kernel/config/kv"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEtcd is a minimal in-memory etcd v3 JSON gateway.
+type fakeEtcd struct {
+	mu       sync.Mutex
+	revision int64
+	data     map[string]string
+	changed  chan struct{}
+}
+
+func newFakeEtcd(data map[string]string) *fakeEtcd {
+	return &fakeEtcd{revision: 1, data: data, changed: make(chan struct{})}
+}
+
+func (f *fakeEtcd) put(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.data[key] = value
+	f.revision++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeEtcd) header() map[string]string {
+	return map[string]string{"revision": strconv.FormatInt(f.revision, 10)}
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		var req struct {
+			Key      []byte `json:"key"`
+			RangeEnd []byte `json:"range_end"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		kvs := []map[string]any{}
+		for key, value := range f.data {
+			if bytes.Compare([]byte(key), req.Key) >= 0 && bytes.Compare([]byte(key), req.RangeEnd) < 0 {
+				kvs = append(kvs, map[string]any{"key": []byte(key), "value": []byte(value)})
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"header": f.header(), "kvs": kvs})
+
+	case "/v3/watch":
+		var req struct {
+			CreateRequest struct {
+				StartRevision int64 `json:"start_revision,string"`
+			} `json:"create_request"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		f.mu.Lock()
+		changed, missed := f.changed, f.revision >= req.CreateRequest.StartRevision
+		json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"header": f.header(), "created": true}})
+		f.mu.Unlock()
+		w.(http.Flusher).Flush()
+
+		if !missed {
+			select {
+			case <-changed:
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{
+			"header": f.header(),
+			"events": []map[string]any{{"type": "PUT"}},
+		}})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestEtcdLoad(t *testing.T) {
+	server := httptest.NewServer(newFakeEtcd(map[string]string{
+		"/app/DB/Host":  "localhost",
+		"/app/db/port":  "'5432'",
+		"/apps/db/host": "other",
+	}))
+	defer server.Close()
+
+	source := &config.KVSource{Store: &kv.Etcd{Endpoint: server.URL}, Prefix: "/app/"}
+
+	data, err := source.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"db.host": "localhost", "db.port": "5432"}, data)
+}
+
+func TestEtcdWatch(t *testing.T) {
+	fake := newFakeEtcd(map[string]string{"/app/db/host": "localhost"})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	c := config.New(&config.KVSource{Store: &kv.Etcd{Endpoint: server.URL}, Prefix: "/app/"})
+	assert.NoError(t, c.Load())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Watch(ctx)
+
+	fake.put("/app/db/host", "remote")
+
+	assert.Eventually(t, func() bool {
+		return c.Get("db.host", nil) == "remote"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestEtcdUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	_, _, err := (&kv.Etcd{Endpoint: server.URL}).List(context.Background(), "/app/")
+	assert.Error(t, err)
+}
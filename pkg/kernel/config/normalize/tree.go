@@ -0,0 +1,81 @@
+package normalize
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Tree expands flat dotted keys back into nested maps, the inverse of Map.
+//
+// Sections whose keys are the consecutive indexes 0..n-1 become []any. An error
+// is returned when a key holds both a value and nested keys, such as "db" and
+// "db.host", since no nested document can represent it.
+func Tree(input map[string]string) (map[string]any, error) {
+	root := map[string]any{}
+
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		node := root
+		segments := strings.Split(key, ".")
+
+		for i, segment := range segments[:len(segments)-1] {
+			child, exists := node[segment]
+			if !exists {
+				child = map[string]any{}
+				node[segment] = child
+			}
+
+			section, ok := child.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("key %q is both a value and a section", strings.Join(segments[:i+1], "."))
+			}
+			node = section
+		}
+
+		last := segments[len(segments)-1]
+		if _, exists := node[last]; exists {
+			return nil, fmt.Errorf("key %q is both a value and a section", key)
+		}
+		node[last] = input[key]
+	}
+
+	for key, child := range root {
+		root[key] = arrays(child)
+	}
+
+	return root, nil
+}
+
+// arrays converts the sections indexed by 0..n-1 into slices.
+func arrays(value any) any {
+	section, ok := value.(map[string]any)
+	if !ok {
+		return value
+	}
+
+	for key, child := range section {
+		section[key] = arrays(child)
+	}
+
+	if len(section) == 0 {
+		return section
+	}
+
+	items := make([]any, len(section))
+	for key, child := range section {
+		index, err := strconv.Atoi(key)
+		if err != nil || index < 0 || index >= len(items) || strconv.Itoa(index) != key {
+			return section
+		}
+		items[index] = child
+	}
+
+	return items
+}
@@ -0,0 +1,33 @@
+package parser
+
+import (
+	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+)
+
+// Defaults is a configuration parser for default values declared in code.
+//
+// It is meant to be the first parser of a Config, so that every other source
+// overrides it, and its values appear with the source "default".
+type Defaults struct {
+	Values map[string]string
+}
+
+// Type returns the type of the parser.
+func (d *Defaults) Type() string {
+	return "default"
+}
+
+// Load returns a copy of the default values with normalized keys and values.
+//
+// Returns:
+//
+//   - map[string]string: A map containing the normalized default values.
+//   - error: Always nil.
+func (d *Defaults) Load() (map[string]string, error) {
+	config := make(map[string]string, len(d.Values))
+	for key, value := range d.Values {
+		config[normalize.Key(key)] = normalize.Value(value)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,17 @@
+package parser_test
+
+import (
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultsLoad(t *testing.T) {
+	defaults := &parser.Defaults{Values: map[string]string{"SERVER_PORT": "8080", "name": `"app"`}}
+
+	config, err := defaults.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"server.port": "8080", "name": "app"}, config)
+	assert.Equal(t, "default", defaults.Type())
+}
@@ -1,14 +1,28 @@
 package parser
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"os"
+	"path"
 	"strings"
 
 	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
 )
 
 // ENV is a configuration parser for environment variables.
+//
+// When Path is set, the variables are read from a dotenv file of KEY=value
+// lines instead of the environment. Blank lines and "#" comments are skipped,
+// an "export " prefix is allowed, double-quoted values expand \n, \t, \" and
+// \\ escapes and single-quoted values are taken literally.
 type ENV struct {
+	// Path is the dotenv file to read. The environment is read when empty.
+	Path string
+	// FS is the file system Path is read from. Defaults to the OS.
+	FS fs.FileSystem
 }
 
 // Type returns the type of the parser.
@@ -26,6 +40,10 @@ func (e *ENV) Type() string {
 //   - map[string]string: A map containing the normalized environment variables.
 //   - error: An error if one occurs during the loading process.
 func (e *ENV) Load() (map[string]string, error) {
+	if e.Path != "" {
+		return e.loadFile()
+	}
+
 	config := make(map[string]string)
 
 	envVars := os.Environ()
@@ -43,3 +61,114 @@ func (e *ENV) Load() (map[string]string, error) {
 
 	return config, nil
 }
+
+// loadFile Loads the variables of the dotenv file at Path
+//
+// Returns:
+// - map[string]string: normalized configuration map from the file
+// - error: error if the file cannot be read or holds a malformed line
+func (e *ENV) loadFile() (map[string]string, error) {
+	if ext := path.Ext(e.Path); ext != ".env" {
+		return nil, fmt.Errorf("invalid file extension: %s", ext)
+	}
+
+	file, err := fs.Open(e.FS, e.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	config, err := decodeDotenv(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse env file: %w", err)
+	}
+
+	return config, nil
+}
+
+// decodeDotenv Reads KEY=value lines
+//
+// Parameters:
+// - reader: io.Reader - the dotenv content
+//
+// Returns:
+// - map[string]string: normalized configuration map from the content
+// - error: error if a line is malformed
+func decodeDotenv(reader io.Reader) (map[string]string, error) {
+	config := make(map[string]string)
+
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=value", line)
+		}
+
+		value, err := dotenvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		config[normalize.Key(key)] = normalize.Value(value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// dotenvValue Unquotes the value of a KEY=value line
+//
+// Parameters:
+// - value: string - the text after "=", trimmed
+//
+// Returns:
+// - string: the value, without quotes or trailing comment
+// - error: error if a quote is not closed
+func dotenvValue(value string) (string, error) {
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		// An unquoted value ends at a comment.
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = value[:i]
+		}
+		return strings.TrimSpace(value), nil
+	}
+
+	quote := value[0]
+	var b strings.Builder
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == quote:
+			if rest := strings.TrimSpace(value[i+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("unexpected %q after the closing quote", rest)
+			}
+			return b.String(), nil
+		case c == '\\' && quote == '"' && i+1 < len(value):
+			switch next := value[i+1]; next {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '"', '\\':
+				b.WriteByte(next)
+			default:
+				b.WriteByte(c)
+				continue
+			}
+			i++
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", fmt.Errorf("unterminated %c quote", quote)
+}
@@ -6,7 +6,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/kistunium/sdk/pkg/kernel/config/encode"
 	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -61,3 +63,52 @@ func TestENVType(t *testing.T) {
 	envParser := &parser.ENV{}
 	assert.Equal(t, "env", envParser.Type())
 }
+
+func TestEnvLoadFile(t *testing.T) {
+	fsys := fs.NewMem(map[string]string{"/conf/app.env": `
+# Database
+DB_HOST=localhost # comment
+export DB_PORT = 5432
+DB_PASSWORD="p#ss\"word\n"
+DB_NAME='my "db"'
+EMPTY=
+`})
+
+	config, err := (&parser.ENV{Path: "/conf/app.env", FS: fsys}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"db.host":     "localhost",
+		"db.port":     "5432",
+		"db.password": "p#ss\"word",
+		"db.name":     `my "db"`,
+		"empty":       "",
+	}, config)
+}
+
+func TestEnvLoadFileErrors(t *testing.T) {
+	fsys := fs.NewMem(map[string]string{
+		"/bad.env":    "A=1\nB\n",
+		"/quote.env":  "A=\"open\n",
+		"/config.txt": "A=1\n",
+	})
+
+	_, err := (&parser.ENV{Path: "/bad.env", FS: fsys}).Load()
+	assert.ErrorContains(t, err, "line 2: expected KEY=value")
+
+	_, err = (&parser.ENV{Path: "/quote.env", FS: fsys}).Load()
+	assert.ErrorContains(t, err, "line 1: unterminated \" quote")
+
+	_, err = (&parser.ENV{Path: "/config.txt", FS: fsys}).Load()
+	assert.ErrorContains(t, err, "invalid file extension: .txt")
+}
+
+func TestEnvEncodeRoundTrip(t *testing.T) {
+	data := map[string]string{"db.host": "local host", "db.name": `my "db"`, "db.tag": "a#b"}
+
+	var b strings.Builder
+	assert.NoError(t, encode.ENV(&b, data))
+
+	config, err := (&parser.ENV{Path: "/app.env", FS: fs.NewMem(map[string]string{"/app.env": b.String()})}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, data, config)
+}
@@ -0,0 +1,390 @@
+package parser
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+)
+
+const (
+	defaultHTTPTimeout  = 10 * time.Second
+	defaultHTTPBackoff  = 500 * time.Millisecond
+	defaultHTTPInterval = 30 * time.Second
+	defaultHTTPMaxSize  = 16 << 20
+)
+
+// HTTP is a configuration parser for a remote HTTP endpoint.
+//
+// The payload can be JSON, YAML or XML. Custom YAML tags such as "!file" and
+// "!env" are rejected, so that a remote server cannot read local files or
+// environment variables into the configuration. Requests are conditional once an ETag
+// has been received, so polling an unchanged endpoint is cheap. When CachePath
+// is set, the last successfully fetched configuration is written to disk and
+// served back when the endpoint cannot be reached.
+type HTTP struct {
+	// URL is the address of the configuration document.
+	URL string
+	// Format forces the payload format ("json", "yaml" or "xml"). When empty it
+	// is detected from the Content-Type header, then from the URL extension.
+	Format string
+	// Headers are added to every request, e.g. Authorization.
+	Headers map[string]string
+	// TLSConfig customizes the TLS settings of the default client.
+	TLSConfig *tls.Config
+	// Timeout bounds a single request. Defaults to 10 seconds.
+	Timeout time.Duration
+	// Retries is the number of additional attempts after a failed request.
+	Retries int
+	// Backoff is the delay before the first retry, doubled on each attempt.
+	// Defaults to 500 milliseconds.
+	Backoff time.Duration
+	// Interval is the polling period used by Watch. Defaults to 30 seconds.
+	Interval time.Duration
+	// CachePath is the file holding the last-known-good configuration.
+	CachePath string
+	// MaxSize limits the size of the payload in bytes. Defaults to 16 MiB.
+	MaxSize int64
+	// Client replaces the default HTTP client, TLSConfig is then ignored.
+	Client *http.Client
+	// XML configures the mapping of XML payloads, its Path is ignored.
+	XML *XML
+
+	mu        sync.Mutex
+	etag      string
+	last      map[string]string
+	tlsClient *http.Client
+}
+
+// httpCache is the on-disk representation of the last-known-good configuration.
+type httpCache struct {
+	ETag   string            `json:"etag"`
+	Config map[string]string `json:"config"`
+}
+
+// Type Returns the source type "http"
+//
+// Returns:
+// - string: source type "http"
+func (h *HTTP) Type() string {
+	return "http"
+}
+
+// Load Fetches and deserializes the remote configuration
+//
+// This function requests the configuration document, retrying failed attempts
+// with an exponential backoff. A 304 Not Modified answer returns the previously
+// fetched configuration. If every attempt fails and a cache is configured, the
+// cached configuration is returned instead.
+//
+// Parameters:
+// - None
+//
+// Returns:
+// - map[string]string: normalized configuration map
+// - error: error if the endpoint and the cache are both unavailable
+func (h *HTTP) Load() (map[string]string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, err := h.fetch(context.Background()); err != nil {
+		if cached, cacheErr := h.readCache(); cacheErr == nil {
+			return cached, nil
+		}
+
+		return nil, err
+	}
+
+	return maps.Clone(h.last), nil
+}
+
+// Watch Polls the endpoint until the context is done
+//
+// Every Interval, a conditional request is sent and changed is called when the
+// configuration differs from the last fetched one. Failed polls are retried on
+// the next tick.
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the polling loop
+// - changed: func() - called each time the remote configuration changes
+//
+// Returns:
+// - error: the context error once polling stops
+func (h *HTTP) Watch(ctx context.Context, changed func()) error {
+	interval := h.Interval
+	if interval <= 0 {
+		interval = defaultHTTPInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			h.mu.Lock()
+			modified, err := h.fetch(ctx)
+			h.mu.Unlock()
+
+			if err == nil && modified {
+				changed()
+			}
+		}
+	}
+}
+
+// fetch Requests the configuration with retries and stores the result
+//
+// Parameters:
+// - ctx: context.Context - cancels the pending requests and backoff delays
+//
+// Returns:
+// - bool: true if a new configuration was received
+// - error: error if every attempt failed
+func (h *HTTP) fetch(ctx context.Context) (bool, error) {
+	backoff := h.Backoff
+	if backoff <= 0 {
+		backoff = defaultHTTPBackoff
+	}
+
+	var err error
+	for attempt := 0; attempt <= h.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return false, ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		var modified, retry bool
+		modified, retry, err = h.request(ctx)
+		if err == nil {
+			return modified, nil
+		}
+
+		if !retry {
+			break
+		}
+	}
+
+	return false, fmt.Errorf("failed to fetch %s: %w", h.URL, err)
+}
+
+// request Performs a single conditional request
+//
+// Parameters:
+// - ctx: context.Context - cancels the request
+//
+// Returns:
+// - bool: true if a new configuration was received
+// - bool: true if the request can be retried after a failure
+// - error: error if the request failed
+func (h *HTTP) request(ctx context.Context) (bool, bool, error) {
+	timeout := h.Timeout
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return false, false, err
+	}
+
+	for key, value := range h.Headers {
+		req.Header.Set(key, value)
+	}
+	if h.etag != "" && h.last != nil {
+		req.Header.Set("If-None-Match", h.etag)
+	}
+
+	res, err := h.client().Do(req)
+	if err != nil {
+		return false, true, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotModified:
+		return false, false, nil
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError:
+		return false, true, fmt.Errorf("unexpected status: %s", res.Status)
+	case res.StatusCode != http.StatusOK:
+		return false, false, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+
+	config, err := h.decode(res)
+	if err != nil {
+		return false, false, err
+	}
+
+	modified := !maps.Equal(config, h.last)
+	h.etag, h.last = res.Header.Get("ETag"), config
+
+	// The cache is best effort, failing to persist it must not discard a fresh
+	// configuration.
+	_ = h.writeCache()
+
+	return modified, false, nil
+}
+
+// decode Deserializes a response body according to its format
+//
+// Parameters:
+// - res: *http.Response - the successful response
+//
+// Returns:
+// - map[string]string: normalized configuration map
+// - error: error if the format is unknown or the payload is invalid
+func (h *HTTP) decode(res *http.Response) (map[string]string, error) {
+	maxSize := h.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultHTTPMaxSize
+	}
+
+	body := &limitReader{reader: res.Body, remaining: maxSize, max: maxSize}
+
+	switch format := h.format(res); format {
+	case "json":
+		return (&JSON{MaxSize: maxSize}).decode(body)
+	case "yaml":
+		return (&YAML{noTags: true}).decode(body)
+	case "xml":
+		mapping := h.XML
+		if mapping == nil {
+			mapping = &XML{}
+		}
+
+		config := make(map[string]string)
+		if err := mapping.unmarshal(body, config); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal XML: %w", err)
+		}
+		return config, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %q", format)
+	}
+}
+
+// format Resolves the payload format of a response
+//
+// Parameters:
+// - res: *http.Response - the response to inspect
+//
+// Returns:
+// - string: "json", "yaml", "xml" or the unrecognized value
+func (h *HTTP) format(res *http.Response) string {
+	if h.Format != "" {
+		return h.Format
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err == nil {
+		switch mediaType {
+		case "application/json":
+			return "json"
+		case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+			return "yaml"
+		case "application/xml", "text/xml":
+			return "xml"
+		}
+	}
+
+	u, err := url.Parse(h.URL)
+	if err != nil {
+		return ""
+	}
+
+	if ext := strings.TrimPrefix(path.Ext(u.Path), "."); ext != "yml" {
+		return ext
+	}
+
+	return "yaml"
+}
+
+// client Returns the HTTP client used for requests, the client built for
+// TLSConfig is kept for the next requests, h.mu must be held
+func (h *HTTP) client() *http.Client {
+	if h.Client != nil {
+		return h.Client
+	}
+
+	if h.TLSConfig == nil {
+		return http.DefaultClient
+	}
+
+	if h.tlsClient == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = h.TLSConfig
+		h.tlsClient = &http.Client{Transport: transport}
+	}
+
+	return h.tlsClient
+}
+
+// readCache Restores the last-known-good configuration from disk
+//
+// Returns:
+// - map[string]string: the cached configuration
+// - error: error if there is no usable cache
+func (h *HTTP) readCache() (map[string]string, error) {
+	if h.CachePath == "" {
+		return nil, errors.New("no cache configured")
+	}
+
+	content, err := os.ReadFile(h.CachePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache: %w", err)
+	}
+
+	var cache httpCache
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return nil, fmt.Errorf("failed to parse cache: %w", err)
+	}
+
+	if h.last == nil {
+		h.etag, h.last = cache.ETag, cache.Config
+	}
+
+	return maps.Clone(cache.Config), nil
+}
+
+// writeCache Persists the last fetched configuration to disk
+//
+// The cache is written to a temporary file first and renamed into place, so a
+// crash never leaves a truncated cache behind.
+//
+// Returns:
+// - error: error if the cache cannot be written
+func (h *HTTP) writeCache() error {
+	if h.CachePath == "" {
+		return nil
+	}
+
+	content, err := json.Marshal(httpCache{ETag: h.etag, Config: h.last})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache: %w", err)
+	}
+
+	if err := fs.File(h.CachePath).WriteAtomic(content, 0o600); err != nil {
+		return fmt.Errorf("failed to write cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package parser_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPLoad(t *testing.T) {
+	for name, test := range map[string]struct {
+		contentType string
+		content     []byte
+	}{
+		"json": {"application/json", JSONContent},
+		"yaml": {"application/yaml", YAMLContent},
+		"xml":  {"application/xml; charset=utf-8", XMLContent},
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				w.Header().Set("Content-Type", test.contentType)
+				w.Write(test.content)
+			}))
+			defer server.Close()
+
+			httpParser := &parser.HTTP{
+				URL:     server.URL,
+				Headers: map[string]string{"Authorization": "Bearer token"},
+			}
+
+			config, err := httpParser.Load()
+			assert.NoError(t, err)
+			assert.Equal(t, ExpectedConfig, config)
+		})
+	}
+}
+
+func TestHTTPLoadFormatFromURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(YAMLContent)
+	}))
+	defer server.Close()
+
+	httpParser := &parser.HTTP{URL: server.URL + "/config.yml"}
+
+	config, err := httpParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, ExpectedConfig, config)
+}
+
+func TestHTTPLoadConditional(t *testing.T) {
+	var requests, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write(JSONContent)
+	}))
+	defer server.Close()
+
+	httpParser := &parser.HTTP{URL: server.URL, Format: "json"}
+
+	for i := 0; i < 3; i++ {
+		config, err := httpParser.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, ExpectedConfig, config)
+	}
+
+	assert.Equal(t, int32(3), requests.Load())
+	assert.Equal(t, int32(2), notModified.Load())
+}
+
+func TestHTTPLoadRetries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(JSONContent)
+	}))
+	defer server.Close()
+
+	httpParser := &parser.HTTP{URL: server.URL, Format: "json", Retries: 2, Backoff: time.Millisecond}
+
+	config, err := httpParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, ExpectedConfig, config)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestHTTPLoadNoRetryOnClientError(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	httpParser := &parser.HTTP{URL: server.URL, Format: "json", Retries: 3, Backoff: time.Millisecond}
+
+	config, err := httpParser.Load()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestHTTPLoadUntrustedPayload(t *testing.T) {
+	t.Setenv("HTTP_TEST_SECRET", "secret")
+
+	for name, content := range map[string]string{
+		"env":  "token: !env HTTP_TEST_SECRET\n",
+		"file": "passwd: !file /etc/passwd\n",
+		"size": "key: " + strings.Repeat("x", 64) + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/yaml")
+				w.Write([]byte(content))
+			}))
+			defer server.Close()
+
+			httpParser := &parser.HTTP{URL: server.URL, MaxSize: 32}
+
+			config, err := httpParser.Load()
+			assert.Error(t, err)
+			assert.Nil(t, config)
+		})
+	}
+}
+
+func TestHTTPLoadTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(JSONContent)
+	}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	httpParser := &parser.HTTP{URL: server.URL, TLSConfig: &tls.Config{RootCAs: pool}}
+
+	config, err := httpParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, ExpectedConfig, config)
+	assert.Nil(t, httpParser.Client)
+}
+
+func TestHTTPLoadFallsBackToCache(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "config.cache")
+
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write(JSONContent)
+	}))
+	defer server.Close()
+
+	config, err := (&parser.HTTP{URL: server.URL, Format: "json", CachePath: cachePath}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, ExpectedConfig, config)
+
+	down.Store(true)
+
+	config, err = (&parser.HTTP{URL: server.URL, Format: "json", CachePath: cachePath}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, ExpectedConfig, config)
+
+	config, err = (&parser.HTTP{URL: server.URL, Format: "json"}).Load()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+}
+
+func TestHTTPWatch(t *testing.T) {
+	var version atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + string(rune('0'+version.Load())) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`{"version": ` + etag + `}`))
+	}))
+	defer server.Close()
+
+	httpParser := &parser.HTTP{URL: server.URL, Format: "json", Interval: 5 * time.Millisecond}
+	_, err := httpParser.Load()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	changes := make(chan struct{}, 1)
+	go httpParser.Watch(ctx, func() { changes <- struct{}{} })
+
+	version.Store(1)
+
+	select {
+	case <-changes:
+	case <-ctx.Done():
+		t.Fatal("no change notified")
+	}
+
+	config, err := httpParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"version": "1"}, config)
+}
+
+func TestHTTPType(t *testing.T) {
+	httpParser := &parser.HTTP{}
+	assert.Equal(t, "http", httpParser.Type())
+}
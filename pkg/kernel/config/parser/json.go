@@ -1,17 +1,44 @@
 package parser
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"os"
 	"path"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
 )
 
+const (
+	defaultJSONMaxDepth = 64
+	defaultJSONMaxSize  = 16 << 20
+)
+
+// JSON is a configuration parser for JSON files.
+//
+// Numbers keep their exact text, so that large integers are not rounded, and
+// null values are mapped to empty strings.
+//
+// Files with the .jsonc or .json5 extension, or any file when Lenient is set,
+// are read in lenient mode, which accepts "//" and "/* */" comments, trailing
+// commas, unquoted keys, single-quoted strings and hexadecimal numbers.
 type JSON struct {
 	Path string
+	// FS is the file system Path is read from. Defaults to the OS.
+	FS fs.FileSystem
+	// Lenient accepts the JSONC and JSON5 extensions whatever the file extension.
+	Lenient bool
+	// MaxDepth limits the nesting of objects and arrays. Defaults to 64.
+	MaxDepth int
+	// MaxSize limits the size of the document in bytes. Defaults to 16 MiB.
+	MaxSize int64
 }
 
 // Type Returns the file type "json"
@@ -39,31 +66,239 @@ func (j *JSON) Type() string {
 // - map[string]string: normalized configuration map from the JSON content
 // - error: error if any issues occurred during loading or deserialization
 func (j *JSON) Load() (map[string]string, error) {
-	if ext := path.Ext(j.Path); ext != ".json" {
+	ext := path.Ext(j.Path)
+	if ext != ".json" && ext != ".jsonc" && ext != ".json5" {
 		return nil, fmt.Errorf("invalid file extension: %s", ext)
 	}
 
-	var config map[string]any
-
 	// Open the JSON file
-	file, err := os.Open(j.Path)
+	file, err := fs.Open(j.FS, j.Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open JSON file: %w", err)
 	}
 	defer file.Close()
 
-	// Read the file content
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read JSON file: %w", err)
+	decoder := *j
+	decoder.Lenient = j.Lenient || ext != ".json"
+
+	return decoder.decode(file)
+}
+
+// Watch Watches the JSON file until the context is done
+//
+// Changes are notified by the file system; on the OS, saving the file by
+// renaming a temporary file over it is reported as a single change.
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the watch
+// - changed: func() - called each time the file is created, modified or removed
+//
+// Returns:
+// - error: the context error once watching stops
+func (j *JSON) Watch(ctx context.Context, changed func()) error {
+	return watchFile(ctx, j.FS, j.Path, changed)
+}
+
+// decode Reads and deserializes JSON content
+//
+// This function decodes the content token by token and flattens it directly
+// into the configuration map, so that only the lenient mode holds the whole
+// content in memory. Numbers keep their exact text, null values are mapped to
+// empty strings and syntax errors report the line and column of the content.
+//
+// Parameters:
+// - reader: io.Reader - the JSON content
+//
+// Returns:
+// - map[string]string: normalized configuration map from the JSON content
+// - error: error if any issues occurred during reading or deserialization
+func (j *JSON) decode(reader io.Reader) (map[string]string, error) {
+	maxSize := j.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultJSONMaxSize
 	}
 
-	// Unmarshal the JSON content into the config map
-	err = json.Unmarshal(content, &config)
-	if err != nil {
+	lines := &lineReader{reader: &limitReader{reader: reader, remaining: maxSize, max: maxSize}}
+	locate := lines.position
+
+	var input io.Reader = lines
+	if j.Lenient {
+		content, err := io.ReadAll(lines)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JSON content: %w", err)
+		}
+
+		strict, source, err := toStrictJSON(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON content: %w", err)
+		}
+
+		input = bytes.NewReader(strict)
+		locate = func(offset int64) string { return position(content, source(offset)) }
+	}
+
+	decoder := json.NewDecoder(input)
+	decoder.UseNumber()
+
+	config := make(map[string]string)
+	if err := j.flatten(decoder, nil, 0, config); err != nil {
+		var sizeErr *sizeError
+		var syntaxErr *json.SyntaxError
+		switch {
+		case errors.As(err, &sizeErr):
+		case errors.As(err, &syntaxErr):
+			err = fmt.Errorf("%s: %w", locate(syntaxErr.Offset-1), err)
+		default:
+			err = fmt.Errorf("%s: %w", locate(decoder.InputOffset()), err)
+		}
 		return nil, fmt.Errorf("failed to parse JSON content: %w", err)
 	}
 
-	// Normalize the config map and return it
-	return normalize.Map(config), nil
+	if _, err := decoder.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("invalid content after top-level value")
+		}
+		return nil, fmt.Errorf("failed to parse JSON content: %s: %w", locate(decoder.InputOffset()), err)
+	}
+
+	return config, nil
+}
+
+// flatten Reads a value from the decoder and populates the output map
+//
+// Parameters:
+// - decoder: *json.Decoder - the decoder positioned before the value
+// - prefix: []string - the key segments leading to the value
+// - depth: int - the nesting depth of the value
+// - output: map[string]string - the map to populate
+//
+// Returns:
+// - error: error if the content is malformed or exceeds the maximum depth
+func (j *JSON) flatten(decoder *json.Decoder, prefix []string, depth int, output map[string]string) error {
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if delim, ok := token.(json.Delim); ok && (delim == '{' || len(prefix) > 0) {
+		maxDepth := j.MaxDepth
+		if maxDepth <= 0 {
+			maxDepth = defaultJSONMaxDepth
+		}
+
+		if depth++; depth > maxDepth {
+			return fmt.Errorf("document exceeds a depth of %d", maxDepth)
+		}
+
+		for i := 0; decoder.More(); i++ {
+			segment := strconv.Itoa(i)
+			if delim == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				segment = key.(string)
+			}
+
+			if err := j.flatten(decoder, append(prefix, segment), depth, output); err != nil {
+				return err
+			}
+		}
+
+		// Consume the closing delimiter
+		_, err := decoder.Token()
+		return err
+	}
+
+	if len(prefix) == 0 {
+		if token == nil {
+			return nil
+		}
+		return errors.New("document is not an object")
+	}
+
+	var value string
+	switch token := token.(type) {
+	case string:
+		value = normalize.Value(token)
+	case json.Number:
+		value = token.String()
+	case bool:
+		value = strconv.FormatBool(token)
+	}
+
+	output[normalize.Key(strings.Join(prefix, "."))] = value
+
+	return nil
+}
+
+// sizeError reports a document larger than the maximum size.
+type sizeError struct {
+	max int64
+}
+
+// Error implements the error interface.
+func (e *sizeError) Error() string {
+	return fmt.Sprintf("document exceeds %d bytes", e.max)
+}
+
+// limitReader fails once more than remaining bytes are read.
+type limitReader struct {
+	reader    io.Reader
+	remaining int64
+	max       int64
+}
+
+// Read implements io.Reader.
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, &sizeError{max: l.max}
+	}
+
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+
+	n, err := l.reader.Read(p)
+	if l.remaining -= int64(n); l.remaining < 0 {
+		return 0, &sizeError{max: l.max}
+	}
+
+	return n, err
+}
+
+// lineReader records the offsets of the line breaks read, so that an offset
+// can be located without keeping the content.
+type lineReader struct {
+	reader io.Reader
+	read   int64
+	breaks []int64
+}
+
+// Read implements io.Reader.
+func (l *lineReader) Read(p []byte) (int, error) {
+	n, err := l.reader.Read(p)
+	for i, c := range p[:n] {
+		if c == '\n' {
+			l.breaks = append(l.breaks, l.read+int64(i))
+		}
+	}
+	l.read += int64(n)
+
+	return n, err
+}
+
+// position Formats the line and column of an offset
+func (l *lineReader) position(offset int64) string {
+	line, _ := slices.BinarySearch(l.breaks, offset)
+	start := int64(-1)
+	if line > 0 {
+		start = l.breaks[line-1]
+	}
+
+	return fmt.Sprintf("line %d, column %d", line+1, offset-start)
 }
@@ -2,6 +2,7 @@ package parser_test
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/kistunium/sdk/pkg/kernel/config/parser"
@@ -49,3 +50,118 @@ func TestJSONType(t *testing.T) {
 	jsonParser := &parser.JSON{}
 	assert.Equal(t, "json", jsonParser.Type())
 }
+
+func TestJSONLoadLenient(t *testing.T) {
+	content := []byte(`// Application settings
+{
+	/* the application name */
+	app: {name: 'Kitsunium "SDK"', id: 0x1F,},
+	'debug': true, // trailing comment
+	servers: [
+		"a",
+		"b",
+	],
+	offset: +3,
+}
+`)
+
+	for _, ext := range []string{".jsonc", ".json5"} {
+		t.Run(ext, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config"+ext)
+			assert.NoError(t, os.WriteFile(file, content, 0o600))
+
+			config, err := (&parser.JSON{Path: file}).Load()
+			assert.NoError(t, err)
+			assert.Equal(t, map[string]string{
+				"app.name":  `Kitsunium "SDK"`,
+				"app.id":    "31",
+				"debug":     "true",
+				"servers.0": "a",
+				"servers.1": "b",
+				"offset":    "3",
+			}, config)
+		})
+	}
+
+	file := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, os.WriteFile(file, content, 0o600))
+
+	_, err := (&parser.JSON{Path: file}).Load()
+	assert.ErrorContains(t, err, "line 1, column 1")
+
+	config, err := (&parser.JSON{Path: file, Lenient: true}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "31", config["app.id"])
+}
+
+func TestJSONLoadErrorPosition(t *testing.T) {
+	for name, test := range map[string]struct {
+		ext      string
+		content  string
+		position string
+	}{
+		"strict":               {".json", "{\n  \"a\": 1,\n  \"b\" 2\n}", "line 3, column 7"},
+		"lenient":              {".jsonc", "// comment\n{\n  a: 1, /* x */ 'b': 2 3\n}", "line 3, column 24"},
+		"unterminated string":  {".jsonc", "{\n  a: 'value\n}", "line 2, column 6"},
+		"unterminated comment": {".jsonc", "{\n  /* a: 1\n}", "line 2, column 3"},
+		"identifier":           {".json5", "{\n  a: Infinity\n}", "line 2, column 6"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "config"+test.ext)
+			assert.NoError(t, os.WriteFile(file, []byte(test.content), 0o600))
+
+			config, err := (&parser.JSON{Path: file}).Load()
+			assert.ErrorContains(t, err, test.position)
+			assert.Nil(t, config)
+		})
+	}
+}
+
+func TestJSONLoadPrecision(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	content := `{"id": 1234567890123456789, "ratio": 0.1000000000000000055511, "big": 1e400, "empty": null, "list": [], "nested": {"n": -42}}`
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	config, err := (&parser.JSON{Path: file}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"id":       "1234567890123456789",
+		"ratio":    "0.1000000000000000055511",
+		"big":      "1e400",
+		"empty":    "",
+		"nested.n": "-42",
+	}, config)
+}
+
+func TestJSONLoadLimits(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.json")
+	content := `{"a": {"b": {"c": [1, 2]}}}`
+	assert.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	_, err := (&parser.JSON{Path: file, MaxDepth: 4}).Load()
+	assert.NoError(t, err)
+
+	_, err = (&parser.JSON{Path: file, MaxDepth: 3}).Load()
+	assert.ErrorContains(t, err, "depth of 3")
+
+	_, err = (&parser.JSON{Path: file, MaxSize: int64(len(content))}).Load()
+	assert.NoError(t, err)
+
+	_, err = (&parser.JSON{Path: file, MaxSize: int64(len(content)) - 1}).Load()
+	assert.ErrorContains(t, err, "exceeds")
+
+	for name, content := range map[string]string{
+		"not an object": `[1, 2]`,
+		"trailing data": `{"a": 1} {"b": 2}`,
+		"truncated":     `{"a": {"b": 1}`,
+		"empty":         ``,
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+			config, err := (&parser.JSON{Path: file}).Load()
+			assert.Error(t, err)
+			assert.Nil(t, config)
+		})
+	}
+}
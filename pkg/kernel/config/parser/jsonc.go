@@ -0,0 +1,269 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonc converts lenient JSON into strict JSON.
+//
+// The accepted extensions are the ones of JSONC and the common subset of JSON5:
+// "//" and "/* */" comments, trailing commas, unquoted keys, single-quoted
+// strings, hexadecimal numbers and a leading "+" sign. Because the output does
+// not keep the offsets of the input, every rewritten token records where it
+// comes from so that errors reported on the output can be located in the
+// input.
+type jsonc struct {
+	input   []byte
+	output  bytes.Buffer
+	offsets [][2]int
+	pos     int
+}
+
+// toStrictJSON Converts lenient JSON content into strict JSON
+//
+// Parameters:
+// - input: []byte - the lenient JSON content
+//
+// Returns:
+// - []byte: the strict JSON content
+// - func(int64) int64: maps an offset of the output onto the input
+// - error: error with the line and column of the first invalid token
+func toStrictJSON(input []byte) ([]byte, func(int64) int64, error) {
+	c := &jsonc{input: input}
+	if err := c.convert(); err != nil {
+		return nil, nil, err
+	}
+
+	return c.output.Bytes(), c.source, nil
+}
+
+// convert Scans the input and writes the strict output
+func (c *jsonc) convert() error {
+	for c.pos < len(c.input) {
+		c.offsets = append(c.offsets, [2]int{c.output.Len(), c.pos})
+
+		switch ch := c.input[c.pos]; {
+		case ch == '/':
+			if err := c.comment(); err != nil {
+				return err
+			}
+		case ch == '"' || ch == '\'':
+			if err := c.string(ch); err != nil {
+				return err
+			}
+		case ch == ',':
+			if next := c.peek(c.pos + 1); next != '}' && next != ']' {
+				c.output.WriteByte(ch)
+			}
+			c.pos++
+		case ch == '+' || ch == '-' || ch >= '0' && ch <= '9':
+			if err := c.number(); err != nil {
+				return err
+			}
+		case ch == '_' || ch == '$' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z':
+			if err := c.identifier(); err != nil {
+				return err
+			}
+		default:
+			c.output.WriteByte(ch)
+			c.pos++
+		}
+	}
+
+	return nil
+}
+
+// comment Skips a "//" or "/* */" comment
+func (c *jsonc) comment() error {
+	switch c.peekRaw(c.pos + 1) {
+	case '/':
+		end := bytes.IndexByte(c.input[c.pos:], '\n')
+		if end < 0 {
+			c.pos = len(c.input)
+		} else {
+			c.pos += end
+		}
+	case '*':
+		end := bytes.Index(c.input[c.pos+2:], []byte("*/"))
+		if end < 0 {
+			return c.errorf(c.pos, "unterminated comment")
+		}
+		// Keep the line breaks so that the output lines match the input.
+		c.output.Write(bytes.Repeat([]byte("\n"), bytes.Count(c.input[c.pos:c.pos+2+end], []byte("\n"))))
+		c.pos += end + 4
+	default:
+		return c.errorf(c.pos, "invalid character '/'")
+	}
+
+	return nil
+}
+
+// string Copies a double-quoted string or converts a single-quoted one
+func (c *jsonc) string(quote byte) error {
+	start := c.pos
+	c.output.WriteByte('"')
+	c.pos++
+
+	for c.pos < len(c.input) {
+		ch := c.input[c.pos]
+		switch {
+		case ch == quote:
+			c.output.WriteByte('"')
+			c.pos++
+			return nil
+		case ch == '\\' && c.pos+1 < len(c.input):
+			next := c.input[c.pos+1]
+			switch {
+			case next == '\'':
+				c.output.WriteByte('\'')
+			case next == '\n':
+				// JSON5 line continuation.
+			default:
+				c.output.Write([]byte{ch, next})
+			}
+			c.pos += 2
+		case ch == '"':
+			c.output.WriteString(`\"`)
+			c.pos++
+		case ch == '\n':
+			return c.errorf(start, "unterminated string")
+		default:
+			c.output.WriteByte(ch)
+			c.pos++
+		}
+	}
+
+	return c.errorf(start, "unterminated string")
+}
+
+// number Copies a number, converting hexadecimal notation to decimal
+func (c *jsonc) number() error {
+	start := c.pos
+	if c.input[c.pos] == '+' {
+		c.pos++
+	} else if c.input[c.pos] == '-' {
+		c.output.WriteByte('-')
+		c.pos++
+	}
+
+	if c.pos+1 < len(c.input) && c.input[c.pos] == '0' && (c.input[c.pos+1] == 'x' || c.input[c.pos+1] == 'X') {
+		end := c.pos + 2
+		for end < len(c.input) && strings.IndexByte("0123456789abcdefABCDEF", c.input[end]) >= 0 {
+			end++
+		}
+
+		value, err := strconv.ParseUint(string(c.input[c.pos+2:end]), 16, 64)
+		if err != nil {
+			return c.errorf(start, "invalid hexadecimal number")
+		}
+
+		c.output.WriteString(strconv.FormatUint(value, 10))
+		c.pos = end
+		return nil
+	}
+
+	for c.pos < len(c.input) && strings.IndexByte("0123456789.eE+-", c.input[c.pos]) >= 0 {
+		c.output.WriteByte(c.input[c.pos])
+		c.pos++
+	}
+
+	return nil
+}
+
+// identifier Copies a literal or quotes an unquoted key
+func (c *jsonc) identifier() error {
+	start := c.pos
+	for c.pos < len(c.input) {
+		ch := c.input[c.pos]
+		if ch != '_' && ch != '$' && !(ch >= 'a' && ch <= 'z') && !(ch >= 'A' && ch <= 'Z') && !(ch >= '0' && ch <= '9') {
+			break
+		}
+		c.pos++
+	}
+
+	name := string(c.input[start:c.pos])
+	if c.peek(c.pos) == ':' {
+		c.output.WriteString(strconv.Quote(name))
+		return nil
+	}
+
+	switch name {
+	case "true", "false", "null":
+		c.output.WriteString(name)
+		return nil
+	default:
+		return c.errorf(start, "invalid identifier %q", name)
+	}
+}
+
+// peek Returns the next significant character at or after i, skipping
+// whitespace and comments, or 0 at the end of the input
+func (c *jsonc) peek(i int) byte {
+	for i < len(c.input) {
+		switch ch := c.input[i]; {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '/' && c.peekRaw(i+1) == '/':
+			end := bytes.IndexByte(c.input[i:], '\n')
+			if end < 0 {
+				return 0
+			}
+			i += end
+		case ch == '/' && c.peekRaw(i+1) == '*':
+			end := bytes.Index(c.input[i+2:], []byte("*/"))
+			if end < 0 {
+				return 0
+			}
+			i += end + 4
+		default:
+			return ch
+		}
+	}
+
+	return 0
+}
+
+// peekRaw Returns the character at i, or 0 at the end of the input
+func (c *jsonc) peekRaw(i int) byte {
+	if i < len(c.input) {
+		return c.input[i]
+	}
+
+	return 0
+}
+
+// source Maps an offset of the output onto the input
+func (c *jsonc) source(offset int64) int64 {
+	i := sort.Search(len(c.offsets), func(i int) bool { return int64(c.offsets[i][0]) > offset }) - 1
+	if i < 0 {
+		return offset
+	}
+
+	return int64(c.offsets[i][1]) + offset - int64(c.offsets[i][0])
+}
+
+// errorf Returns an error located at an input offset
+func (c *jsonc) errorf(offset int, format string, args ...any) error {
+	return fmt.Errorf("%s: %s", position(c.input, int64(offset)), fmt.Sprintf(format, args...))
+}
+
+// position Formats the line and column of an offset
+//
+// Parameters:
+// - content: []byte - the document
+// - offset: int64 - the byte offset in the document
+//
+// Returns:
+// - string: the "line L, column C" position, both starting at 1
+func position(content []byte, offset int64) string {
+	offset = min(max(offset, 0), int64(len(content)))
+	before := content[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(offset) - bytes.LastIndexByte(before, '\n')
+
+	return fmt.Sprintf("line %d, column %d", line, column)
+}
@@ -3,7 +3,12 @@ package parser_test
 import (
 	"encoding/json"
 	"encoding/xml"
+	"os"
+	"testing"
 
+	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
 )
 
@@ -127,3 +132,41 @@ var (
 		"metadata.tags.tag.2":         "tag3",
 	}
 )
+
+func TestParsersFileSystem(t *testing.T) {
+	fsys := fs.NewMem(map[string]string{
+		"/conf/app.json": string(JSONContent),
+		"/conf/app.yaml": string(YAMLContent),
+		"/conf/app.xml":  string(XMLContent),
+		"/conf/app.toml": `app = {name = "TestApp", version = "1.0"}`,
+		"/conf/app.env":  "APP_NAME=TestApp\nAPP_VERSION=1.0\n",
+	})
+
+	parsers := []interface {
+		Load() (map[string]string, error)
+	}{
+		&parser.JSON{Path: "/conf/app.json", FS: fsys},
+		&parser.YAML{Path: "/conf/app.yaml", FS: fsys},
+		&parser.XML{Path: "/conf/app.xml", FS: fsys},
+	}
+
+	for _, p := range parsers {
+		config, err := p.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, ExpectedConfig, config)
+	}
+
+	for _, p := range []interface {
+		Load() (map[string]string, error)
+	}{
+		&parser.TOML{Path: "/conf/app.toml", FS: fsys},
+		&parser.ENV{Path: "/conf/app.env", FS: fsys},
+	} {
+		config, err := p.Load()
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"app.name": "TestApp", "app.version": "1.0"}, config)
+	}
+
+	_, err := (&parser.JSON{Path: "/conf/missing.json", FS: fsys}).Load()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,739 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+)
+
+const (
+	defaultTOMLMaxDepth = 64
+	defaultTOMLMaxSize  = 16 << 20
+)
+
+var (
+	tomlInteger  = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)$|^0x[0-9A-Fa-f](_?[0-9A-Fa-f])*$|^0o[0-7](_?[0-7])*$|^0b[01](_?[01])*$`)
+	tomlFloat    = regexp.MustCompile(`^[+-]?(0|[1-9](_?[0-9])*)(\.[0-9](_?[0-9])*)?([eE][+-]?[0-9](_?[0-9])*)?$|^[+-]?(inf|nan)$`)
+	tomlDateTime = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([Tt ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?([Zz]|[+-]\d{2}:\d{2})?)?$|^\d{2}:\d{2}(:\d{2}(\.\d+)?)?$`)
+	tomlDate     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// TOML is a configuration parser for TOML files.
+//
+// Tables and arrays of tables are mapped like JSON objects and arrays, e.g.
+// the second [[servers]] table gives "servers.1.host". Strings are unescaped,
+// integers are written in decimal, e.g. 0x1F gives "31", and the underscores of
+// numbers are dropped. Floats, booleans and dates keep their text.
+type TOML struct {
+	Path string
+	// FS is the file system Path is read from. Defaults to the OS.
+	FS fs.FileSystem
+	// MaxDepth limits the nesting of arrays and inline tables. Defaults to 64.
+	MaxDepth int
+	// MaxSize limits the size of the document in bytes. Defaults to 16 MiB.
+	MaxSize int64
+}
+
+// Type Returns the file type "toml"
+//
+// This function returns a string indicating the type of file to handle.
+//
+// Parameters:
+// - None
+//
+// Returns:
+// - string: file type "toml"
+func (t *TOML) Type() string {
+	return "toml"
+}
+
+// Load Loads and deserializes the TOML file
+//
+// This function opens the TOML file at the specified path, parses it and
+// flattens it into a map[string]string.
+//
+// Parameters:
+// - None
+//
+// Returns:
+// - map[string]string: normalized configuration map from the TOML content
+// - error: error if any issues occurred during loading or parsing
+func (t *TOML) Load() (map[string]string, error) {
+	if ext := path.Ext(t.Path); ext != ".toml" {
+		return nil, fmt.Errorf("invalid file extension: %s", ext)
+	}
+
+	file, err := fs.Open(t.FS, t.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open TOML file: %w", err)
+	}
+	defer file.Close()
+
+	return t.decode(file)
+}
+
+// Watch Watches the TOML file until the context is done
+//
+// Changes are notified by the file system; on the OS, saving the file by
+// renaming a temporary file over it is reported as a single change.
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the watch
+// - changed: func() - called each time the file is created, modified or removed
+//
+// Returns:
+// - error: the context error once watching stops
+func (t *TOML) Watch(ctx context.Context, changed func()) error {
+	return watchFile(ctx, t.FS, t.Path, changed)
+}
+
+// decode Reads and parses TOML content
+//
+// Parameters:
+// - reader: io.Reader - the TOML content
+//
+// Returns:
+// - map[string]string: normalized configuration map from the TOML content
+// - error: error if the content is malformed or exceeds the limits
+func (t *TOML) decode(reader io.Reader) (map[string]string, error) {
+	maxSize := t.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultTOMLMaxSize
+	}
+
+	maxDepth := t.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultTOMLMaxDepth
+	}
+
+	content, err := io.ReadAll(&limitReader{reader: reader, remaining: maxSize, max: maxSize})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TOML content: %w", err)
+	}
+
+	if !utf8.Valid(content) {
+		return nil, fmt.Errorf("failed to parse TOML content: invalid UTF-8")
+	}
+
+	p := &tomlParser{content: string(content), maxDepth: maxDepth, root: newTOMLTable()}
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("failed to parse TOML content: %w", err)
+	}
+
+	config := make(map[string]string)
+	tomlFlatten(p.root, nil, config)
+
+	return config, nil
+}
+
+// tomlTable is a table of a TOML document.
+type tomlTable struct {
+	entries map[string]any
+	// defined is set once the table has a [header].
+	defined bool
+	// inline tables cannot be extended once closed.
+	inline bool
+}
+
+// tomlTables is an array of tables, extended by each [[header]].
+type tomlTables struct {
+	tables []*tomlTable
+}
+
+// tomlScalar is a number, boolean or date, written as is.
+type tomlScalar string
+
+// newTOMLTable Creates an empty table
+func newTOMLTable() *tomlTable {
+	return &tomlTable{entries: map[string]any{}}
+}
+
+// tomlParser parses a TOML document into tables.
+type tomlParser struct {
+	content  string
+	pos      int
+	depth    int
+	maxDepth int
+	root     *tomlTable
+	current  *tomlTable
+}
+
+// parse Parses the whole document
+func (p *tomlParser) parse() error {
+	p.current = p.root
+
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+
+		switch p.peek() {
+		case '#':
+			p.skipComment()
+		case '\n':
+			p.pos++
+		case '\r':
+			if err := p.lineEnd(); err != nil {
+				return err
+			}
+		case '[':
+			if err := p.header(); err != nil {
+				return err
+			}
+		default:
+			if err := p.keyValue(p.current); err != nil {
+				return err
+			}
+			if err := p.lineEnd(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// header Parses a [table] or [[array of tables]] header
+func (p *tomlParser) header() error {
+	array := strings.HasPrefix(p.content[p.pos:], "[[")
+	if array {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+
+	closing := "]"
+	if array {
+		closing = "]]"
+	}
+	if !strings.HasPrefix(p.content[p.pos:], closing) {
+		return p.errorf("expected %q after the table name", closing)
+	}
+	p.pos += len(closing)
+
+	parent, err := p.table(p.root, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+	switch existing := parent.entries[last].(type) {
+	case nil:
+		table := newTOMLTable()
+		table.defined = true
+		if array {
+			parent.entries[last] = &tomlTables{tables: []*tomlTable{table}}
+		} else {
+			parent.entries[last] = table
+		}
+		p.current = table
+	case *tomlTables:
+		if !array {
+			return p.errorf("table %q is already defined as an array of tables", strings.Join(keys, "."))
+		}
+		table := newTOMLTable()
+		table.defined = true
+		existing.tables = append(existing.tables, table)
+		p.current = table
+	case *tomlTable:
+		if array || existing.defined || existing.inline {
+			return p.errorf("table %q is already defined", strings.Join(keys, "."))
+		}
+		existing.defined = true
+		p.current = existing
+	default:
+		return p.errorf("key %q is already defined", strings.Join(keys, "."))
+	}
+
+	return p.lineEnd()
+}
+
+// table Returns the table of dotted keys, creating the missing ones
+func (p *tomlParser) table(from *tomlTable, keys []string) (*tomlTable, error) {
+	table := from
+	for i, key := range keys {
+		switch child := table.entries[key].(type) {
+		case nil:
+			next := newTOMLTable()
+			table.entries[key] = next
+			table = next
+		case *tomlTable:
+			if child.inline {
+				return nil, p.errorf("inline table %q cannot be extended", strings.Join(keys[:i+1], "."))
+			}
+			table = child
+		case *tomlTables:
+			table = child.tables[len(child.tables)-1]
+		default:
+			return nil, p.errorf("key %q is already defined", strings.Join(keys[:i+1], "."))
+		}
+	}
+
+	return table, nil
+}
+
+// keyValue Parses a key = value pair into a table
+func (p *tomlParser) keyValue(table *tomlTable) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+
+	if p.eof() || p.peek() != '=' {
+		return p.errorf("expected \"=\" after key %q", strings.Join(keys, "."))
+	}
+	p.pos++
+	p.skipBlank()
+
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	parent, err := p.table(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+
+	last := keys[len(keys)-1]
+	if _, exists := parent.entries[last]; exists {
+		return p.errorf("key %q is already defined", strings.Join(keys, "."))
+	}
+	parent.entries[last] = value
+
+	return nil
+}
+
+// key Parses a bare, quoted or dotted key
+func (p *tomlParser) key() ([]string, error) {
+	var keys []string
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("expected a key")
+		}
+
+		var key string
+		var err error
+		switch c := p.peek(); {
+		case c == '"':
+			key, err = p.basicString()
+		case c == '\'':
+			key, err = p.literalString()
+		default:
+			start := p.pos
+			for !p.eof() && tomlBare(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("invalid character %q in key", c)
+			}
+			key = p.content[start:p.pos]
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+		p.skipBlank()
+		if p.eof() || p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+// value Parses a value
+func (p *tomlParser) value() (any, error) {
+	if p.eof() {
+		return nil, p.errorf("expected a value")
+	}
+
+	rest := p.content[p.pos:]
+	switch {
+	case strings.HasPrefix(rest, `"""`):
+		return p.multilineString(`"""`)
+	case strings.HasPrefix(rest, `'''`):
+		return p.multilineString(`'''`)
+	case rest[0] == '"':
+		return p.basicString()
+	case rest[0] == '\'':
+		return p.literalString()
+	case rest[0] == '[':
+		return p.array()
+	case rest[0] == '{':
+		return p.inlineTable()
+	}
+
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	// Dates and times may be separated by a space.
+	if tomlDate.MatchString(p.content[start:p.pos]) && strings.HasPrefix(p.content[p.pos:], " ") &&
+		p.pos+1 < len(p.content) && p.content[p.pos+1] >= '0' && p.content[p.pos+1] <= '9' {
+		p.pos++
+		for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+			p.pos++
+		}
+	}
+
+	token := p.content[start:p.pos]
+	switch {
+	case token == "true" || token == "false":
+		return tomlScalar(token), nil
+	case tomlInteger.MatchString(token):
+		n, err := strconv.ParseInt(token, 0, 64)
+		if err != nil {
+			return nil, p.errorf("invalid integer %q", token)
+		}
+		return tomlScalar(strconv.FormatInt(n, 10)), nil
+	case tomlFloat.MatchString(token):
+		return tomlScalar(strings.ReplaceAll(token, "_", "")), nil
+	case tomlDateTime.MatchString(token):
+		return tomlScalar(token), nil
+	default:
+		return nil, p.errorf("invalid value %q", token)
+	}
+}
+
+// array Parses an array, which may span several lines
+func (p *tomlParser) array() (any, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	p.pos++
+	items := []any{}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return items, nil
+		}
+
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+
+		p.skipSpace()
+		if p.eof() {
+			return nil, p.errorf("unterminated array")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return items, nil
+		default:
+			return nil, p.errorf("expected \",\" or \"]\" in array")
+		}
+	}
+}
+
+// inlineTable Parses an inline table
+func (p *tomlParser) inlineTable() (any, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	p.pos++
+	table := newTOMLTable()
+	p.skipBlank()
+	if !p.eof() && p.peek() == '}' {
+		p.pos++
+		table.inline = true
+		return table, nil
+	}
+
+	for {
+		if err := p.keyValue(table); err != nil {
+			return nil, err
+		}
+
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("unterminated inline table")
+		}
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			table.inline = true
+			return table, nil
+		default:
+			return nil, p.errorf("expected \",\" or \"}\" in inline table")
+		}
+	}
+}
+
+// basicString Parses a "basic string" with escapes
+func (p *tomlParser) basicString() (string, error) {
+	p.pos++
+
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+
+		switch c := p.peek(); c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if err := p.escape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// literalString Parses a 'literal string', without escapes
+func (p *tomlParser) literalString() (string, error) {
+	p.pos++
+
+	end := strings.IndexAny(p.content[p.pos:], "'\n")
+	if end < 0 || p.content[p.pos+end] == '\n' {
+		return "", p.errorf("unterminated string")
+	}
+
+	value := p.content[p.pos : p.pos+end]
+	p.pos += end + 1
+
+	return value, nil
+}
+
+// multilineString Parses a multi-line basic or literal string, delimited by
+// three quotes
+func (p *tomlParser) multilineString(delim string) (string, error) {
+	p.pos += len(delim)
+	// A newline right after the opening delimiter is trimmed.
+	if strings.HasPrefix(p.content[p.pos:], "\r\n") {
+		p.pos += 2
+	} else if strings.HasPrefix(p.content[p.pos:], "\n") {
+		p.pos++
+	}
+
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated string")
+		}
+
+		if strings.HasPrefix(p.content[p.pos:], delim) {
+			// Up to two quotes can precede the closing delimiter.
+			quotes := 0
+			for p.pos+quotes < len(p.content) && p.content[p.pos+quotes] == delim[0] && quotes < 5 {
+				quotes++
+			}
+			b.WriteString(strings.Repeat(delim[:1], quotes-3))
+			p.pos += quotes
+			return b.String(), nil
+		}
+
+		c := p.peek()
+		if c != '\\' || delim == `'''` {
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		// A backslash ending a line trims the following blanks and newlines.
+		if rest := strings.TrimLeft(p.content[p.pos+1:], " \t"); strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+			p.pos = len(p.content) - len(strings.TrimLeft(rest, " \t\r\n"))
+			continue
+		}
+
+		if err := p.escape(&b); err != nil {
+			return "", err
+		}
+	}
+}
+
+// escape Parses an escape sequence of a basic string
+func (p *tomlParser) escape(b *strings.Builder) error {
+	p.pos++
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case 'e':
+		b.WriteByte(0x1b)
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.content) {
+			return p.errorf("invalid escape \\%c", c)
+		}
+		code, err := strconv.ParseUint(p.content[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return p.errorf("invalid escape \\%c%s", c, p.content[p.pos:p.pos+size])
+		}
+		b.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return p.errorf("invalid escape \\%c", c)
+	}
+
+	return nil
+}
+
+// lineEnd Expects the end of a line, after blanks and a comment
+func (p *tomlParser) lineEnd() error {
+	p.skipBlank()
+	p.skipComment()
+
+	switch {
+	case p.eof():
+		return nil
+	case strings.HasPrefix(p.content[p.pos:], "\n"):
+		p.pos++
+	case strings.HasPrefix(p.content[p.pos:], "\r\n"):
+		p.pos += 2
+	default:
+		return p.errorf("unexpected %q at the end of the line", p.peek())
+	}
+
+	return nil
+}
+
+// enter Enters a nested array or inline table
+func (p *tomlParser) enter() error {
+	if p.depth++; p.depth > p.maxDepth {
+		return p.errorf("document exceeds a depth of %d", p.maxDepth)
+	}
+
+	return nil
+}
+
+// leave Leaves a nested array or inline table
+func (p *tomlParser) leave() {
+	p.depth--
+}
+
+// skipBlank Skips spaces and tabs
+func (p *tomlParser) skipBlank() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment Skips a comment up to the end of the line
+func (p *tomlParser) skipComment() {
+	if p.eof() || p.peek() != '#' {
+		return
+	}
+
+	if end := strings.IndexByte(p.content[p.pos:], '\n'); end >= 0 {
+		p.pos += end
+	} else {
+		p.pos = len(p.content)
+	}
+	if strings.HasSuffix(p.content[:p.pos], "\r") {
+		p.pos--
+	}
+}
+
+// skipSpace Skips blanks, newlines and comments, inside arrays
+func (p *tomlParser) skipSpace() {
+	for {
+		p.skipBlank()
+		p.skipComment()
+		if p.eof() || (p.peek() != '\n' && p.peek() != '\r') {
+			return
+		}
+		p.pos++
+	}
+}
+
+// eof Reports whether the whole content is parsed
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.content)
+}
+
+// peek Returns the next byte
+func (p *tomlParser) peek() byte {
+	return p.content[p.pos]
+}
+
+// errorf Returns an error located at the current line
+func (p *tomlParser) errorf(format string, args ...any) error {
+	line := strings.Count(p.content[:min(p.pos, len(p.content))], "\n") + 1
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// tomlBare Reports whether a character can appear in a bare key
+func tomlBare(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// tomlFlatten Populates the output map with a parsed value
+//
+// Parameters:
+// - value: any - the value, a table, an array or a scalar
+// - prefix: []string - the key segments leading to the value
+// - output: map[string]string - the map to populate
+func tomlFlatten(value any, prefix []string, output map[string]string) {
+	switch v := value.(type) {
+	case *tomlTable:
+		for key, child := range v.entries {
+			tomlFlatten(child, append(prefix, key), output)
+		}
+	case *tomlTables:
+		for i, table := range v.tables {
+			tomlFlatten(table, append(prefix, strconv.Itoa(i)), output)
+		}
+	case []any:
+		for i, item := range v {
+			tomlFlatten(item, append(prefix, strconv.Itoa(i)), output)
+		}
+	case string:
+		output[normalize.Key(strings.Join(prefix, "."))] = normalize.Value(v)
+	case tomlScalar:
+		output[normalize.Key(strings.Join(prefix, "."))] = string(v)
+	}
+}
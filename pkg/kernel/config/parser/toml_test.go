@@ -0,0 +1,137 @@
+package parser_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/encode"
+	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTOML(t *testing.T, content string) (map[string]string, error) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return (&parser.TOML{Path: path}).Load()
+}
+
+func TestTOMLLoad(t *testing.T) {
+	config, err := loadTOML(t, `
+# Application
+title = "Kitsunium" # trailing comment
+"quoted key" = 'C:\path'
+site."google.com" = true
+hex = 0x1F
+big = 1_000_000
+pi = 3.141_5
+neg = -inf
+date = 1979-05-27 07:32:00Z
+text = """
+Roses are red \
+   and blue\t"""
+raw = '''
+no \escape'''
+ports = [ 8000,
+  8001, # comment
+]
+point = { x = 1, y.z = "two" }
+
+[database]
+server = "192.168.1.1"
+
+[database.timeouts]
+read = 5
+
+[[servers]]
+name = "alpha"
+
+[servers.tls]
+enabled = true
+
+[[servers]]
+name = "beta"
+`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"title":                  "Kitsunium",
+		"quoted key":             `C:\path`,
+		"site.google.com":        "true",
+		"hex":                    "31",
+		"big":                    "1000000",
+		"pi":                     "3.1415",
+		"neg":                    "-inf",
+		"date":                   "1979-05-27 07:32:00Z",
+		"text":                   "Roses are red and blue",
+		"raw":                    `no \escape`,
+		"ports.0":                "8000",
+		"ports.1":                "8001",
+		"point.x":                "1",
+		"point.y.z":              "two",
+		"database.server":        "192.168.1.1",
+		"database.timeouts.read": "5",
+		"servers.0.name":         "alpha",
+		"servers.0.tls.enabled":  "true",
+		"servers.1.name":         "beta",
+	}, config)
+}
+
+func TestTOMLLoadErrors(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		message string
+	}{
+		"duplicate key":     {"a = 1\na = 2", "line 2: key \"a\" is already defined"},
+		"duplicate table":   {"[a]\n[a]", "line 2: table \"a\" is already defined"},
+		"inline extended":   {"a = {b = 1}\n[a.c]", "line 2: inline table \"a\" cannot be extended"},
+		"unterminated":      {"a = \"x", "line 1: unterminated string"},
+		"invalid value":     {"a = yes", "line 1: invalid value \"yes\""},
+		"leading zero":      {"a = 012", "line 1: invalid value \"012\""},
+		"overflow":          {"a = 9223372036854775808", "line 1: invalid integer \"9223372036854775808\""},
+		"missing equals":    {"a 1", "line 1: expected \"=\" after key \"a\""},
+		"trailing content":  {"a = 1 b", "line 1: unexpected 'b' at the end of the line"},
+		"unclosed array":    {"a = [1,\n2", "line 2: unterminated array"},
+		"invalid escape":    {`a = "\q"`, `line 1: invalid escape \q`},
+		"table over value":  {"a = 1\n[a]", "line 2: key \"a\" is already defined"},
+		"array over table":  {"[a]\n[[a]]", "line 2: table \"a\" is already defined"},
+		"table over tables": {"[[a]]\n[a]", "line 2: table \"a\" is already defined as an array of tables"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadTOML(t, test.content)
+			assert.ErrorContains(t, err, test.message)
+		})
+	}
+}
+
+func TestTOMLMaxDepth(t *testing.T) {
+	fsys := fs.NewMem(map[string]string{"/app.toml": "a = " + strings.Repeat("[", 5) + strings.Repeat("]", 5)})
+
+	_, err := (&parser.TOML{Path: "/app.toml", FS: fsys, MaxDepth: 4}).Load()
+	assert.ErrorContains(t, err, "document exceeds a depth of 4")
+
+	_, err = (&parser.TOML{Path: "/app.toml", FS: fsys}).Load()
+	assert.NoError(t, err)
+}
+
+func TestTOMLEncodeRoundTrip(t *testing.T) {
+	var b strings.Builder
+	assert.NoError(t, encode.TOML(&b, ExpectedConfig))
+
+	config, err := loadTOML(t, b.String())
+	assert.NoError(t, err)
+	assert.Equal(t, ExpectedConfig, config)
+}
+
+func TestNoTOMLLoad(t *testing.T) {
+	_, err := (&parser.TOML{Path: "config.json"}).Load()
+	assert.ErrorContains(t, err, "invalid file extension: .json")
+}
+
+func TestTOMLType(t *testing.T) {
+	assert.Equal(t, "toml", (&parser.TOML{}).Type())
+}
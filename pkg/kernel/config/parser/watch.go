@@ -0,0 +1,16 @@
+package parser
+
+import (
+	"context"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+)
+
+// watchFile Watches a configuration file of a file system, the OS when nil
+func watchFile(ctx context.Context, fsys fs.FileSystem, path string, changed func()) error {
+	if fsys == nil {
+		fsys = fs.OS{}
+	}
+
+	return fsys.Watch(ctx, path, changed)
+}
@@ -1,17 +1,81 @@
 package parser
 
 import (
+	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
-	"os"
+	"maps"
 	"path"
+	"strconv"
+	"strings"
 
 	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
 )
 
+const (
+	defaultXMLMaxDepth = 64
+	defaultXMLMaxSize  = 16 << 20
+
+	// xmlNamespace is the namespace bound to the reserved "xml" prefix.
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+)
+
+// XML is a configuration parser for XML files.
+//
+// The document is mapped onto dotted keys as follows:
+//
+//   - The root element is not part of the keys, each nested element adds its
+//     name as a key segment: <config><db><host>x</host></db></config> gives
+//     "db.host".
+//   - Sibling elements sharing a name are indexed from 0: two <server> elements
+//     give "server.0" and "server.1". Keys listed in Arrays are always indexed,
+//     even for a single element.
+//   - Indexed elements with three or more distinct child elements or
+//     attributes drop their name, as in earlier versions: two <option> elements
+//     holding <name>, <value> and an enabled attribute give "options.0.name" and
+//     "options.1.name". KeepNames disables this rule.
+//   - Wrapper elements listed in Unwrap drop the name of their repeated child:
+//     <options><option/><option/></options> gives "options.0" and "options.1".
+//   - Attributes are children of their element, prefixed by AttributePrefix.
+//     Without prefix, an attribute named like a child element is stored with
+//     the "@" marker so that neither value is lost.
+//   - The text of an element is its value. Text split by child elements (mixed
+//     content) is joined with a single space. CDATA sections are kept verbatim,
+//     other text is trimmed and unquoted.
+//   - Namespace declarations are not mapped. Names keep their namespace
+//     according to Namespaces.
+//
+// Arrays and Unwrap keys are given without array indexes, e.g. "servers.server".
 type XML struct {
 	Path string
+	// FS is the file system Path is read from. Defaults to the OS.
+	FS fs.FileSystem
+	// Namespaces selects how namespaces appear in keys: "" drops them, "prefix"
+	// keeps the document prefix ("ns:name") and "uri" keeps the namespace URI
+	// ("{uri}name").
+	Namespaces string
+	// AttributePrefix is prepended to attribute names, e.g. "@".
+	AttributePrefix string
+	// Arrays lists the element keys always mapped as arrays.
+	Arrays []string
+	// Unwrap lists the wrapper element keys whose repeated children are indexed
+	// directly under the wrapper.
+	Unwrap []string
+	// KeepNames keeps the name of every indexed element in its key, only the
+	// wrappers listed in Unwrap drop it.
+	KeepNames bool
+	// Entities declares the entities allowed in addition to the XML ones.
+	Entities map[string]string
+	// AllowDTD accepts documents with a <!DOCTYPE> declaration.
+	AllowDTD bool
+	// MaxDepth limits the nesting of elements. Defaults to 64.
+	MaxDepth int
+	// MaxSize limits the size of the document in bytes. Defaults to 16 MiB.
+	MaxSize int64
 }
 
 // Type Returns the file type "xml"
@@ -45,7 +109,7 @@ func (x *XML) Load() (map[string]string, error) {
 
 	var config map[string]string = make(map[string]string)
 
-	file, err := os.Open(x.Path)
+	file, err := fs.Open(x.FS, x.Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
 	}
@@ -58,198 +122,275 @@ func (x *XML) Load() (map[string]string, error) {
 	return config, nil
 }
 
+// Watch Watches the XML file until the context is done
+//
+// Changes are notified by the file system; on the OS, saving the file by
+// renaming a temporary file over it is reported as a single change.
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the watch
+// - changed: func() - called each time the file is created, modified or removed
+//
+// Returns:
+// - error: the context error once watching stops
+func (x *XML) Watch(ctx context.Context, changed func()) error {
+	return watchFile(ctx, x.FS, x.Path, changed)
+}
+
 // unmarshal Deserializes the XML content into the provided output map
 //
-// This function reads the content from the provided file reader, processes the XML tokens,
-// and fills the output map with the extracted values.
+// This function reads the content from the provided file reader, builds the
+// element tree, and fills the output map following the mapping rules of XML.
 //
 // Parameters:
 // - file: io.Reader - the reader for the XML file content
-// - output: map[string]any - the map to populate with the deserialized XML data
+// - output: map[string]string - the map to populate with the deserialized XML data
 //
 // Returns:
 // - error: error if any issues occurred during deserialization
 func (x *XML) unmarshal(file io.Reader, output map[string]string) error {
-	decoder := xml.NewDecoder(file)
-	n := makeNode("", nil)
+	if x.Namespaces != "" && x.Namespaces != "prefix" && x.Namespaces != "uri" {
+		return fmt.Errorf("invalid namespaces mode: %q", x.Namespaces)
+	}
+
+	maxSize := x.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultXMLMaxSize
+	}
+
+	content, err := io.ReadAll(io.LimitReader(file, maxSize+1))
+	if err != nil {
+		return err
+	}
+
+	if int64(len(content)) > maxSize {
+		return fmt.Errorf("document exceeds %d bytes", maxSize)
+	}
+
+	root, err := x.parse(content)
+	if err != nil {
+		return err
+	}
+
+	if root != nil {
+		x.flatten(root, "", "", output)
+	}
+
+	return nil
+}
+
+// element is an XML element of the document tree.
+type element struct {
+	name     string
+	text     []string
+	attrs    []attribute
+	children []*element
+	parent   *element
+}
+
+// attribute is an XML attribute with its mapped name.
+type attribute struct {
+	name  string
+	value string
+}
+
+// parse Builds the element tree of a document
+//
+// Parameters:
+// - content: []byte - the XML document
+//
+// Returns:
+// - *element: the root element, nil for an empty document
+// - error: error if the document is malformed or exceeds the limits
+func (x *XML) parse(content []byte) (*element, error) {
+	maxDepth := x.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = defaultXMLMaxDepth
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Entity = x.Entities
+
+	var (
+		root, current *element
+		depth         int
+		prefixes      = []map[string]string{{xmlNamespace: "xml"}}
+	)
 
 	for {
+		offset := decoder.InputOffset()
+
 		token, err := decoder.Token()
 		if err == io.EOF {
 			break
 		}
 
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		switch token := token.(type) {
 		case xml.StartElement:
-			n = n.inNode(normalize.Key(token.Name.Local))
+			if depth++; depth > maxDepth {
+				return nil, fmt.Errorf("document exceeds a depth of %d", maxDepth)
+			}
+
+			scope, cloned := prefixes[len(prefixes)-1], false
+			for _, attr := range token.Attr {
+				if attr.Name.Space == "xmlns" {
+					if !cloned {
+						scope, cloned = maps.Clone(scope), true
+					}
+					scope[attr.Value] = attr.Name.Local
+				}
+			}
+			prefixes = append(prefixes, scope)
+
+			e := &element{name: x.name(token.Name, scope), parent: current}
 			for _, attr := range token.Attr {
-				n = n.inNode(normalize.Key(attr.Name.Local))
-				n.value = normalize.Value(attr.Value)
-				n = n.outNode()
+				if attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+					continue
+				}
+				e.attrs = append(e.attrs, attribute{name: x.name(attr.Name, scope), value: attr.Value})
+			}
+
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("multiple root elements")
+				}
+				root = e
+			} else {
+				current.children = append(current.children, e)
 			}
+			current = e
 		case xml.EndElement:
-			n = n.outNode()
+			depth--
+			prefixes = prefixes[:len(prefixes)-1]
+			current = current.parent
 		case xml.CharData:
-			n.value = normalize.Value(string(token))
+			if current == nil {
+				continue
+			}
+
+			if raw := content[offset:decoder.InputOffset()]; bytes.HasPrefix(raw, []byte("<![CDATA[")) {
+				current.text = append(current.text, string(token))
+			} else if value := normalize.Value(string(token)); value != "" {
+				current.text = append(current.text, value)
+			}
+		case xml.Directive:
+			if !x.AllowDTD && bytes.HasPrefix(bytes.TrimSpace(token), []byte("DOCTYPE")) {
+				return nil, errors.New("DTD is not allowed")
+			}
 		}
 	}
 
-	return explore(n, output)
+	return root, nil
 }
 
-// explore Recursively explores the node tree to populate the output map
-//
-// This function recursively traverses the node structure and populates the provided map with the node paths
-// and their corresponding values.
+// name Maps an element or attribute name onto a key segment
 //
 // Parameters:
-// - n: *node - the root node to explore
-// - output: map[string]any - the map to populate with node values
+// - name: xml.Name - the resolved name
+// - scope: map[string]string - the namespace URI to prefix declarations in scope
 //
 // Returns:
-// - error: error if any issues occurred during exploration
-func explore(n *node, output map[string]string) error {
-	if n.value != "" {
-		output[n.getPath()] = n.value
+// - string: the normalized key segment
+func (x *XML) name(name xml.Name, scope map[string]string) string {
+	if name.Space == "" {
+		return normalize.Key(name.Local)
 	}
 
-	if len(n.children) > 0 {
-		for _, child := range n.children {
-			if err := explore(child, output); err != nil {
-				return err
-			}
+	switch x.Namespaces {
+	case "prefix":
+		if prefix := scope[name.Space]; prefix != "" {
+			return normalize.Key(prefix + ":" + name.Local)
 		}
+		// Undeclared prefixes are not resolved by the decoder.
+		if !strings.Contains(name.Space, ":") {
+			return normalize.Key(name.Space + ":" + name.Local)
+		}
+	case "uri":
+		return normalize.Key("{" + name.Space + "}" + name.Local)
 	}
 
-	return nil
+	return normalize.Key(name.Local)
 }
 
-// node represents a node in the XML structure
-type node struct {
-	value         string
-	name          string
-	children      []*node
-	childrenNames map[string]int
-	id            int
-	parent        *node
-}
-
-// hasMultipleChildName Checks if the node has multiple children with the same name
-//
-// This function checks if the node has more than one child with the same name.
+// flatten Recursively populates the output map with an element and its children
 //
 // Parameters:
-// - None
-//
-// Returns:
-// - bool: true if the node has multiple children with the same name, false otherwise
-func (t *node) hasMultipleChildName() bool {
-	count := 0
-	for range t.childrenNames {
-		if count > 1 {
-			return true
-		}
-		count++
+// - e: *element - the element to flatten
+// - key: string - the key of the element
+// - template: string - the key of the element without array indexes
+// - output: map[string]string - the map to populate
+func (x *XML) flatten(e *element, key, template string, output map[string]string) {
+	if len(e.text) > 0 {
+		output[key] = strings.Join(e.text, " ")
 	}
 
-	return false
-}
-
-// getPath Retrieves the full path of the node
-//
-// This function constructs and returns the full hierarchical path of the node, including
-// its parent's path and its own identifier if necessary.
-//
-// Parameters:
-// - None
-//
-// Returns:
-// - string: the full path of the node
-func (t *node) getPath() string {
-	if t.parent == nil {
-		return ""
+	counts := map[string]int{}
+	for _, child := range e.children {
+		counts[child.name]++
 	}
 
-	if t.parent.childrenNames[t.name] > 1 {
-		if t.hasMultipleChildName() {
-			return fmt.Sprintf("%s.%d", t.parent.getPath(), t.id)
+	for _, attr := range e.attrs {
+		name := x.AttributePrefix + attr.name
+		if x.AttributePrefix == "" && counts[attr.name] > 0 {
+			name = "@" + attr.name
 		}
-
-		return fmt.Sprintf("%s.%s.%d", t.parent.getPath(), t.name, t.id)
+		output[join(key, name)] = normalize.Value(attr.value)
 	}
 
-	if path := t.parent.getPath(); path != "" {
-		return fmt.Sprintf("%s.%s", path, t.name)
-	}
+	indexes := map[string]int{}
+	for _, child := range e.children {
+		childTemplate := join(template, child.name)
+		childKey := join(key, child.name)
 
-	return t.name
-}
+		if counts[child.name] > 1 || contains(x.Arrays, childTemplate) {
+			index := strconv.Itoa(indexes[child.name])
+			indexes[child.name]++
 
-// inNode Adds a new child node
-//
-// This function creates a new child node with the given name and appends it to the list of children.
-// It also updates the child name count for tracking multiple children with the same name.
-//
-// Parameters:
-// - name: string - the name of the child node
-//
-// Returns:
-// - *node: pointer to the newly created child node
-func (t *node) inNode(name string) *node {
-	if t.parent == nil && t.name == "" {
-		t.name = name
-		return t
+			if len(counts) == 1 && contains(x.Unwrap, template) || !x.KeepNames && child.fields() > 2 {
+				childKey = join(key, index)
+			} else {
+				childKey = join(childKey, index)
+			}
+		}
+
+		x.flatten(child, childKey, childTemplate, output)
 	}
+}
 
-	n := makeNode(name, t)
-	t.children = append(t.children, n)
-	t.childrenNames = map[string]int{}
-	for _, n := range t.children {
-		t.childrenNames[n.name]++
-		n.id = t.childrenNames[n.name] - 1
+// fields Returns the number of distinct names among the child elements and the
+// attributes of an element
+func (e *element) fields() int {
+	names := map[string]bool{}
+	for _, child := range e.children {
+		names[child.name] = true
+	}
+	for _, attr := range e.attrs {
+		names[attr.name] = true
 	}
 
-	return n
+	return len(names)
 }
 
-// makeNode Creates a new node
-//
-// This function creates and returns a new node with the given name and parent.
-//
-// Parameters:
-// - name: string - the name of the new node
-// - parent: *node - the parent node of the new node
-//
-// Returns:
-// - *node: pointer to the newly created node
-func makeNode(name string, parent *node) *node {
-	n := &node{
-		parent:        parent,
-		name:          name,
-		childrenNames: map[string]int{},
-		children:      []*node{},
+// join Appends a segment to a key
+func join(key, segment string) string {
+	if key == "" {
+		return segment
 	}
 
-	return n
+	return key + "." + segment
 }
 
-// outNode Moves back to the parent node
-//
-// This function moves the pointer from the current node back to its parent.
-//
-// Parameters:
-// - None
-//
-// Returns:
-// - *node: pointer to the parent node, or the current node if it has no parent
-func (t *node) outNode() *node {
-	if t.parent == nil {
-		return t
+// contains Reports whether a list of keys holds a key
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if normalize.Key(k) == key {
+			return true
+		}
 	}
 
-	return t.parent
+	return false
 }
@@ -1,7 +1,12 @@
 package parser_test
 
 import (
+	"flag"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/kistunium/sdk/pkg/kernel/config/parser"
@@ -49,3 +54,98 @@ func TestXMLType(t *testing.T) {
 	xmlParser := parser.XML{}
 	assert.Equal(t, "xml", xmlParser.Type())
 }
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestXMLMapping(t *testing.T) {
+	for name, test := range map[string]struct {
+		document string
+		parser   parser.XML
+	}{
+		"namespaces_local":  {"namespaces", parser.XML{}},
+		"namespaces_prefix": {"namespaces", parser.XML{Namespaces: "prefix"}},
+		"namespaces_uri":    {"namespaces", parser.XML{Namespaces: "uri"}},
+		"attributes":        {"attributes", parser.XML{}},
+		"attributes_marker": {"attributes", parser.XML{AttributePrefix: "@"}},
+		"mixed":             {"mixed", parser.XML{}},
+		"cdata":             {"cdata", parser.XML{}},
+		"arrays":            {"arrays", parser.XML{Arrays: []string{"servers.server"}, Unwrap: []string{"options", "features"}}},
+		"entities":          {"entities", parser.XML{Entities: map[string]string{"company": "Kitsunium"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			xmlParser := test.parser
+			xmlParser.Path = filepath.Join("testdata", "xml", test.document+".xml")
+
+			config, err := xmlParser.Load()
+			assert.NoError(t, err)
+
+			lines := make([]string, 0, len(config))
+			for key, value := range config {
+				lines = append(lines, fmt.Sprintf("%s = %s", key, value))
+			}
+			slices.Sort(lines)
+			actual := strings.Join(lines, "\n") + "\n"
+
+			golden := filepath.Join("testdata", "xml", name+".golden")
+			if *update {
+				assert.NoError(t, os.WriteFile(golden, []byte(actual), 0o644))
+			}
+
+			expected, err := os.ReadFile(golden)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), actual)
+		})
+	}
+}
+
+func TestXMLLimits(t *testing.T) {
+	for name, test := range map[string]struct {
+		content string
+		parser  parser.XML
+	}{
+		"dtd":       {`<!DOCTYPE config [<!ENTITY a "b">]><config><a>&a;</a></config>`, parser.XML{}},
+		"entity":    {`<config><a>&unknown;</a></config>`, parser.XML{}},
+		"depth":     {`<a><b><c><d>deep</d></c></b></a>`, parser.XML{MaxDepth: 3}},
+		"size":      {`<config><a>value</a></config>`, parser.XML{MaxSize: 10}},
+		"namespace": {`<config/>`, parser.XML{Namespaces: "unknown"}},
+		"roots":     {`<a>1</a><b>2</b>`, parser.XML{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			xmlParser := test.parser
+			xmlParser.Path = filepath.Join(t.TempDir(), "config.xml")
+			assert.NoError(t, os.WriteFile(xmlParser.Path, []byte(test.content), 0o600))
+
+			config, err := xmlParser.Load()
+			assert.Error(t, err)
+			assert.Nil(t, config)
+		})
+	}
+
+	xmlParser := parser.XML{Path: filepath.Join(t.TempDir(), "config.xml"), AllowDTD: true}
+	assert.NoError(t, os.WriteFile(xmlParser.Path, []byte(`<!DOCTYPE config><config><a>b</a></config>`), 0o600))
+
+	config, err := xmlParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "b"}, config)
+}
+
+func TestXMLKeepNames(t *testing.T) {
+	xmlParser := parser.XML{Path: filepath.Join(t.TempDir(), "config.xml")}
+	assert.NoError(t, os.WriteFile(xmlParser.Path, XMLContent, 0o600))
+
+	config, err := xmlParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "option1", config["settings.options.0.name"])
+
+	xmlParser.KeepNames = true
+	config, err = xmlParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "option1", config["settings.options.option.0.name"])
+	assert.Equal(t, "true", config["settings.options.option.0.enabled"])
+	assert.Equal(t, "server1", config["servers.server.0.name"])
+
+	xmlParser.Unwrap = []string{"settings.options"}
+	config, err = xmlParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "option1", config["settings.options.0.name"])
+}
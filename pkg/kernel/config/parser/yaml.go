@@ -1,17 +1,72 @@
 package parser
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
 	"gopkg.in/yaml.v3"
 )
 
+// YAML is a configuration parser for YAML files.
+//
+// Every document of a multi-document file is loaded and merged in order, later
+// documents overriding earlier ones. Anchors, aliases and "<<" merge keys are
+// resolved, non-string keys are used as their text and null values are mapped
+// to empty strings. Scalars with a custom tag, such as "!env HOME", are
+// resolved with the resolvers registered by RegisterYAMLTag.
 type YAML struct {
 	Path string
+	// FS is the file system Path is read from. Defaults to the OS. Files of
+	// "!file" tags are always read from the OS.
+	FS fs.FileSystem
+	// Select keeps only the documents matching a "key=value" condition, e.g.
+	// "profile=prod". Every document is kept when empty.
+	Select string
+
+	// noTags rejects custom tags, for content from untrusted sources.
+	noTags bool
+}
+
+// YAMLTagResolver resolves the value of a scalar with a custom tag.
+//
+// The value is the text of the scalar and dir the directory of the YAML file,
+// empty when the content does not come from a file.
+type YAMLTagResolver func(value string, dir string) (string, error)
+
+// yamlTags is the registry of custom tag resolvers.
+var yamlTags = struct {
+	sync.RWMutex
+	resolvers map[string]YAMLTagResolver
+}{
+	resolvers: map[string]YAMLTagResolver{
+		"!env":  resolveEnvTag,
+		"!file": resolveFileTag,
+	},
+}
+
+// RegisterYAMLTag registers the resolver of a custom tag
+//
+// The "!env NAME" and "!file path" tags are registered by default and can be
+// replaced.
+//
+// Parameters:
+// - tag: string - the tag, including the leading "!"
+// - resolver: YAMLTagResolver - the function resolving the tagged values
+func RegisterYAMLTag(tag string, resolver YAMLTagResolver) {
+	yamlTags.Lock()
+	defer yamlTags.Unlock()
+
+	yamlTags.resolvers[tag] = resolver
 }
 
 // Type Returns the file type "yaml"
@@ -43,27 +98,287 @@ func (y *YAML) Load() (map[string]string, error) {
 		return nil, fmt.Errorf("invalid file extension: %s", ext)
 	}
 
-	var config map[string]any
-
 	// Open the YAML file
-	file, err := os.Open(y.Path)
+	file, err := fs.Open(y.FS, y.Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open YAML file: %w", err)
 	}
 	defer file.Close()
 
-	// Read the file content
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read YAML file: %w", err)
+	return y.decode(file)
+}
+
+// Watch Watches the YAML file until the context is done
+//
+// Changes are notified by the file system; on the OS, saving the file by
+// renaming a temporary file over it is reported as a single change.
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the watch
+// - changed: func() - called each time the file is created, modified or removed
+//
+// Returns:
+// - error: the context error once watching stops
+func (y *YAML) Watch(ctx context.Context, changed func()) error {
+	return watchFile(ctx, y.FS, y.Path, changed)
+}
+
+// decode Reads and deserializes YAML content
+//
+// This function decodes every document of the reader, flattens the documents
+// matching Select and merges them in order.
+//
+// Parameters:
+// - reader: io.Reader - the YAML content
+//
+// Returns:
+// - map[string]string: normalized configuration map from the YAML content
+// - error: error if any issues occurred during reading or deserialization
+func (y *YAML) decode(reader io.Reader) (map[string]string, error) {
+	selectKey, selectValue, selecting := strings.Cut(y.Select, "=")
+	selectKey = normalize.Key(strings.TrimSpace(selectKey))
+	selectValue = normalize.Value(selectValue)
+
+	dir := ""
+	if y.Path != "" {
+		dir = filepath.Dir(y.Path)
+	}
+
+	config := make(map[string]string)
+	matched := false
+
+	decoder := yaml.NewDecoder(reader)
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML content: %w", err)
+		}
+
+		flat := make(map[string]string)
+		if err := y.flatten(&document, nil, dir, flat); err != nil {
+			return nil, fmt.Errorf("failed to parse YAML content: %w", err)
+		}
+
+		if selecting && flat[selectKey] != selectValue {
+			continue
+		}
+
+		matched = true
+		for key, value := range flat {
+			config[key] = value
+		}
+	}
+
+	if selecting && !matched {
+		return nil, fmt.Errorf("no YAML document matches %q", y.Select)
+	}
+
+	return config, nil
+}
+
+// flatten Recursively populates the output map with a YAML node
+//
+// Parameters:
+// - n: *yaml.Node - the node to flatten
+// - prefix: []string - the key segments leading to the node
+// - dir: string - the directory used to resolve relative paths in tags
+// - output: map[string]string - the map to populate
+//
+// Returns:
+// - error: error if the node holds an unsupported key or tag
+func (y *YAML) flatten(n *yaml.Node, prefix []string, dir string, output map[string]string) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, child := range n.Content {
+			if err := y.flatten(child, prefix, dir, output); err != nil {
+				return err
+			}
+		}
+	case yaml.AliasNode:
+		return y.flatten(n.Alias, prefix, dir, output)
+	case yaml.MappingNode:
+		entries, err := yamlEntries(n)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			if err := y.flatten(entry.value, append(prefix, entry.key), dir, output); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range n.Content {
+			if err := y.flatten(child, append(prefix, strconv.Itoa(i)), dir, output); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if len(prefix) == 0 {
+			if n.ShortTag() == "!!null" {
+				return nil
+			}
+			return fmt.Errorf("line %d: document is not a mapping", n.Line)
+		}
+
+		value, err := yamlScalar(n, dir, !y.noTags)
+		if err != nil {
+			return err
+		}
+
+		output[normalize.Key(strings.Join(prefix, "."))] = value
+	}
+
+	return nil
+}
+
+// yamlEntry is a resolved key of a mapping.
+type yamlEntry struct {
+	key   string
+	value *yaml.Node
+}
+
+// yamlEntries Resolves the keys of a mapping, including merge keys
+//
+// Keys explicitly set in the mapping override merged ones, and a mapping
+// merged first overrides the ones merged after it.
+//
+// Parameters:
+// - n: *yaml.Node - the mapping node
+//
+// Returns:
+// - []yamlEntry: the merged keys followed by the explicit keys
+// - error: error if a key is not a scalar or a merge value is not a mapping
+func yamlEntries(n *yaml.Node) ([]yamlEntry, error) {
+	var merged, explicit []yamlEntry
+	seen := map[string]bool{}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if key.Kind == yaml.AliasNode {
+			key = key.Alias
+		}
+
+		if key.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: unsupported non-scalar key", key.Line)
+		}
+
+		if key.ShortTag() != "!!merge" {
+			explicit = append(explicit, yamlEntry{key: key.Value, value: value})
+			seen[key.Value] = true
+			continue
+		}
+
+		if value.Kind == yaml.AliasNode {
+			value = value.Alias
+		}
+
+		sources := []*yaml.Node{value}
+		if value.Kind == yaml.SequenceNode {
+			sources = value.Content
+		}
+
+		for _, source := range sources {
+			if source.Kind == yaml.AliasNode {
+				source = source.Alias
+			}
+
+			if source.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: merge value is not a mapping", source.Line)
+			}
+
+			entries, err := yamlEntries(source)
+			if err != nil {
+				return nil, err
+			}
+			merged = append(merged, entries...)
+		}
+	}
+
+	entries := make([]yamlEntry, 0, len(merged)+len(explicit))
+	for _, entry := range merged {
+		if !seen[entry.key] {
+			entries = append(entries, entry)
+			seen[entry.key] = true
+		}
+	}
+
+	return append(entries, explicit...), nil
+}
+
+// yamlScalar Converts a scalar node into a configuration value
+//
+// Parameters:
+// - n: *yaml.Node - the scalar node
+// - dir: string - the directory used to resolve relative paths in tags
+// - tags: bool - whether custom tags are resolved or rejected
+//
+// Returns:
+// - string: the normalized value
+// - error: error if the tag is unknown, rejected or cannot be resolved
+func yamlScalar(n *yaml.Node, dir string, tags bool) (string, error) {
+	tag := n.ShortTag()
+
+	if strings.HasPrefix(tag, "!") && !strings.HasPrefix(tag, "!!") {
+		if !tags {
+			return "", fmt.Errorf("line %d: custom tag %s is not allowed", n.Line, tag)
+		}
+
+		yamlTags.RLock()
+		resolver, ok := yamlTags.resolvers[tag]
+		yamlTags.RUnlock()
+
+		if !ok {
+			return "", fmt.Errorf("line %d: unknown tag %s", n.Line, tag)
+		}
+
+		value, err := resolver(n.Value, dir)
+		if err != nil {
+			return "", fmt.Errorf("line %d: %s: %w", n.Line, tag, err)
+		}
+
+		return value, nil
+	}
+
+	if tag == "!!null" {
+		return "", nil
+	}
+
+	var value any
+	if err := n.Decode(&value); err != nil {
+		return "", fmt.Errorf("line %d: %w", n.Line, err)
+	}
+
+	return normalize.Value(fmt.Sprintf("%v", value)), nil
+}
+
+// resolveEnvTag resolves "!env NAME" to the value of an environment variable.
+func resolveEnvTag(value string, dir string) (string, error) {
+	resolved, ok := os.LookupEnv(strings.TrimSpace(value))
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", value)
+	}
+
+	return resolved, nil
+}
+
+// resolveFileTag resolves "!file path" to the content of a file, relative to
+// the directory of the YAML file.
+func resolveFileTag(value string, dir string) (string, error) {
+	file := strings.TrimSpace(value)
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
 	}
 
-	// Unmarshal the YAML content into the config map
-	err = yaml.Unmarshal(content, &config)
+	content, err := os.ReadFile(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse YAML content: %w", err)
+		return "", err
 	}
 
-	// Normalize the config map and return it
-	return normalize.Map(config), nil
+	return string(content), nil
 }
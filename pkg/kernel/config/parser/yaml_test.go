@@ -1,10 +1,15 @@
 package parser_test
 
 import (
+	"context"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -50,3 +55,154 @@ func TestYAMLType(t *testing.T) {
 	yamlParser := parser.YAML{}
 	assert.Equal(t, "yaml", yamlParser.Type())
 }
+
+func loadYAML(t *testing.T, yamlParser parser.YAML, content string) (map[string]string, error) {
+	dir := t.TempDir()
+	yamlParser.Path = filepath.Join(dir, "config.yaml")
+	assert.NoError(t, os.WriteFile(yamlParser.Path, []byte(content), 0o600))
+
+	return yamlParser.Load()
+}
+
+func TestYAMLMultiDocument(t *testing.T) {
+	content := strings.Join([]string{
+		"profile: base",
+		"db:",
+		"  host: localhost",
+		"  port: 5432",
+		"---",
+		"profile: prod",
+		"db:",
+		"  host: prod.local",
+		"---",
+		"profile: staging",
+		"db:",
+		"  host: staging.local",
+		"",
+	}, "\n")
+
+	config, err := loadYAML(t, parser.YAML{}, content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"profile": "staging", "db.host": "staging.local", "db.port": "5432"}, config)
+
+	config, err = loadYAML(t, parser.YAML{Select: "profile=prod"}, content)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"profile": "prod", "db.host": "prod.local"}, config)
+
+	_, err = loadYAML(t, parser.YAML{Select: "profile=dev"}, content)
+	assert.Error(t, err)
+}
+
+func TestYAMLMergeKeys(t *testing.T) {
+	content := strings.Join([]string{
+		"defaults: &defaults",
+		"  adapter: postgres",
+		"  pool: 5",
+		"  options:",
+		"    ssl: true",
+		"extra: &extra",
+		"  pool: 20",
+		"  timeout: 30",
+		"development:",
+		"  <<: *defaults",
+		"  database: dev",
+		"production:",
+		"  <<: [*defaults, *extra]",
+		"  options:",
+		"    ssl: false",
+		"hosts: &hosts [a, b]",
+		"replicas: *hosts",
+		"",
+	}, "\n")
+
+	config, err := loadYAML(t, parser.YAML{}, content)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "postgres", config["development.adapter"])
+	assert.Equal(t, "5", config["development.pool"])
+	assert.Equal(t, "true", config["development.options.ssl"])
+	assert.Equal(t, "dev", config["development.database"])
+
+	assert.Equal(t, "5", config["production.pool"])
+	assert.Equal(t, "30", config["production.timeout"])
+	assert.Equal(t, "false", config["production.options.ssl"])
+
+	assert.Equal(t, "b", config["replicas.1"])
+	assert.NotContains(t, config, "development.<<")
+
+	_, err = loadYAML(t, parser.YAML{}, "a: &a [1, 2]\nb:\n  <<: *a\n")
+	assert.Error(t, err)
+}
+
+func TestYAMLKeysAndNulls(t *testing.T) {
+	config, err := loadYAML(t, parser.YAML{}, "ports:\n  80: http\n  443: https\nflags:\n  true: on\nempty: ~\n")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"ports.80":   "http",
+		"ports.443":  "https",
+		"flags.true": "on",
+		"empty":      "",
+	}, config)
+
+	_, err = loadYAML(t, parser.YAML{}, "? [a, b]\n: value\n")
+	assert.Error(t, err)
+
+	_, err = loadYAML(t, parser.YAML{}, "just a scalar\n")
+	assert.Error(t, err)
+}
+
+func TestYAMLCustomTags(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "cert.pem"), []byte("-----BEGIN CERTIFICATE-----\n"), 0o600))
+	t.Setenv("YAML_TEST_HOME", "/home/test")
+
+	parser.RegisterYAMLTag("!upper", func(value string, dir string) (string, error) {
+		return strings.ToUpper(value), nil
+	})
+
+	yamlParser := parser.YAML{Path: filepath.Join(dir, "config.yaml")}
+	assert.NoError(t, os.WriteFile(yamlParser.Path, []byte("home: !env YAML_TEST_HOME\ncert: !file ./cert.pem\nname: !upper service\n"), 0o600))
+
+	config, err := yamlParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"home": "/home/test",
+		"cert": "-----BEGIN CERTIFICATE-----\n",
+		"name": "SERVICE",
+	}, config)
+
+	_, err = loadYAML(t, parser.YAML{}, "home: !env YAML_TEST_MISSING\n")
+	assert.Error(t, err)
+
+	_, err = loadYAML(t, parser.YAML{}, "home: !unknown value\n")
+	assert.Error(t, err)
+}
+
+func TestYAMLWatch(t *testing.T) {
+	fsys := fs.NewMem(map[string]string{"/conf/app.yaml": "a: 1\n"})
+	yamlParser := &parser.YAML{Path: "/conf/app.yaml", FS: fsys}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() {
+		done <- yamlParser.Watch(ctx, func() { changed <- struct{}{} })
+	}()
+
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, fsys.WriteFile("/conf/app.yaml", []byte("a: 2\n"), 0o644))
+		select {
+		case <-changed:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	config, err := yamlParser.Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "2", config["a"])
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
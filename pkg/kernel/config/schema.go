@@ -0,0 +1,168 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec declares a configuration key.
+type Spec struct {
+	Key string `json:"key" yaml:"key"`
+	// Type is one of "string", "int", "float", "bool" or "duration". An empty
+	// type accepts any value.
+	Type        string   `json:"type,omitempty" yaml:"type,omitempty"`
+	Default     string   `json:"default,omitempty" yaml:"default,omitempty"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool     `json:"required,omitempty" yaml:"required,omitempty"`
+	Enum        []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+}
+
+// Schema is a set of key declarations used to validate a configuration.
+type Schema struct {
+	Keys []Spec `json:"keys" yaml:"keys"`
+}
+
+// ValidationError describes a key that does not match its Spec.
+type ValidationError struct {
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Message)
+}
+
+// LoadSchema reads a schema from a JSON or YAML file
+//
+// Parameters:
+// - file: string - The path of the schema, with a .json, .yaml or .yml extension
+//
+// Returns:
+// - *Schema: the loaded schema
+// - error: error if the file cannot be read or parsed
+func LoadSchema(file string) (*Schema, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+
+	var schema Schema
+	switch ext := path.Ext(file); ext {
+	case ".json":
+		err = json.Unmarshal(content, &schema)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &schema)
+	default:
+		return nil, fmt.Errorf("invalid file extension: %s", ext)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	return &schema, nil
+}
+
+// Lookup returns the declaration of a key
+//
+// Parameters:
+// - key: string - The configuration key to look up
+//
+// Returns:
+// - Spec: the declaration of the key
+// - bool: true if the key is declared
+func (s *Schema) Lookup(key string) (Spec, bool) {
+	for _, spec := range s.Keys {
+		if spec.Key == key {
+			return spec, true
+		}
+	}
+
+	return Spec{}, false
+}
+
+// Validate checks a configuration against the schema
+//
+// Every declared key is checked for presence, type and allowed values. All
+// the failures are reported, joined in a single error whose parts are
+// *ValidationError. The values of secret keys, see Snapshot.Secret, are replaced
+// by Redacted in the messages.
+//
+// Parameters:
+// - v: Viewer - The configuration to validate
+//
+// Returns:
+// - error: nil if the configuration is valid
+func (s *Schema) Validate(v Viewer) error {
+	snapshot := v.Snapshot()
+
+	var errs []error
+	for _, spec := range s.Keys {
+		value, ok := snapshot.data[spec.Key]
+		if !ok {
+			if spec.Required && spec.Default == "" {
+				errs = append(errs, &ValidationError{Key: spec.Key, Message: "required key is missing"})
+			}
+			continue
+		}
+
+		shown := strconv.Quote(fmt.Sprint(value))
+		if snapshot.Secret(spec.Key) {
+			shown = Redacted
+		}
+
+		if err := spec.check(fmt.Sprint(value), shown); err != nil {
+			errs = append(errs, &ValidationError{Key: spec.Key, Message: err.Error()})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Check verifies that a value matches the type and allowed values of the spec
+//
+// Parameters:
+// - value: string - The value to check
+//
+// Returns:
+// - error: nil if the value is valid
+func (s Spec) Check(value string) error {
+	return s.check(value, strconv.Quote(value))
+}
+
+// check Verifies a value, shown in the error messages as given
+func (s Spec) check(value, shown string) error {
+	var err error
+	switch s.Type {
+	case "", "string":
+	case "int":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "float":
+		_, err = strconv.ParseFloat(value, 64)
+	case "bool":
+		_, err = strconv.ParseBool(value)
+	case "duration":
+		_, err = time.ParseDuration(value)
+	default:
+		return fmt.Errorf("unknown type %q", s.Type)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s %s", s.Type, shown)
+	}
+
+	if len(s.Enum) > 0 && !slices.Contains(s.Enum, value) {
+		return fmt.Errorf("value %s is not one of %v", shown, s.Enum)
+	}
+
+	return nil
+}
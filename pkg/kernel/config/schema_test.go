@@ -0,0 +1,67 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	schema := &config.Schema{Keys: []config.Spec{
+		{Key: "db.host", Required: true},
+		{Key: "db.port", Type: "int", Required: true},
+		{Key: "db.timeout", Type: "duration"},
+		{Key: "log.level", Enum: []string{"debug", "info"}},
+		{Key: "workers", Type: "int", Required: true, Default: "4"},
+		{Key: "debug", Type: "bool"},
+	}}
+
+	c := config.New(staticParser{
+		"db.port":    "abc",
+		"db.timeout": "5s",
+		"log.level":  "trace",
+		"debug":      "true",
+	})
+	assert.NoError(t, c.Load())
+
+	err := schema.Validate(c)
+	assert.Error(t, err)
+
+	var failures []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var failure *config.ValidationError
+		assert.True(t, errors.As(e, &failure))
+		failures = append(failures, failure.Key)
+	}
+	assert.Equal(t, []string{"db.host", "db.port", "log.level"}, failures)
+
+	valid, err := config.Collect(staticParser{"db.host": "localhost", "db.port": "5432"})
+	assert.NoError(t, err)
+	assert.NoError(t, schema.Validate(valid))
+}
+
+func TestLoadSchema(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonFile := filepath.Join(dir, "schema.json")
+	assert.NoError(t, os.WriteFile(jsonFile, []byte(`{"keys": [{"key": "db.port", "type": "int", "default": "5432"}]}`), 0o600))
+
+	yamlFile := filepath.Join(dir, "schema.yaml")
+	assert.NoError(t, os.WriteFile(yamlFile, []byte("keys:\n  - key: db.port\n    type: int\n    default: \"5432\"\n"), 0o600))
+
+	for _, file := range []string{jsonFile, yamlFile} {
+		schema, err := config.LoadSchema(file)
+		assert.NoError(t, err)
+
+		spec, ok := schema.Lookup("db.port")
+		assert.True(t, ok)
+		assert.Equal(t, config.Spec{Key: "db.port", Type: "int", Default: "5432"}, spec)
+	}
+
+	_, err := config.LoadSchema(filepath.Join(dir, "schema.xml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/normalize"
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"gopkg.in/yaml.v3"
+)
+
+// EncryptFile encrypts selected keys of a configuration file in place
+//
+// Keys are dotted configuration keys, as produced by the parsers. Only the
+// matching leaf values are replaced by ENC[...] envelopes, every other value is
+// left readable for review. Values that are already encrypted are kept as is.
+// YAML (.yaml, .yml), including multi-document files, and JSON (.json) files
+// are supported. XML and ENV files are not: their keys are derived from
+// elements, attributes and variable names by mapping rules that cannot be
+// reversed reliably, so their values must be encrypted with Encrypt and
+// written by hand.
+//
+// Parameters:
+// - file: string - The path of the file to rewrite
+// - provider: KeyProvider - The provider of the encryption key
+// - keys: ...string - The configuration keys to encrypt
+//
+// Returns:
+// - error: error if the file cannot be read, parsed, encrypted or written
+func EncryptFile(file string, provider KeyProvider, keys ...string) error {
+	ext := path.Ext(file)
+	if ext != ".yaml" && ext != ".yml" && ext != ".json" {
+		return fmt.Errorf("unsupported file format %q: only YAML and JSON files can be encrypted in place", ext)
+	}
+
+	key, err := provider.Key()
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	// JSON is a subset of YAML, so both formats share the same document tree.
+	var documents []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to parse file: %w", err)
+		}
+
+		documents = append(documents, &document)
+	}
+
+	if ext == ".json" && len(documents) > 1 {
+		return errors.New("failed to parse file: JSON files hold a single document")
+	}
+
+	selected := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		selected[normalize.Key(k)] = true
+	}
+
+	for _, document := range documents {
+		if err := encryptNode(document, nil, selected, key); err != nil {
+			return err
+		}
+	}
+
+	var output bytes.Buffer
+	if ext == ".json" {
+		if len(documents) > 0 && len(documents[0].Content) > 0 {
+			writeJSON(&output, documents[0].Content[0], "")
+		}
+		output.WriteByte('\n')
+	} else {
+		encoder := yaml.NewEncoder(&output)
+		encoder.SetIndent(2)
+		for _, document := range documents {
+			if err := encoder.Encode(document); err != nil {
+				return fmt.Errorf("failed to encode file: %w", err)
+			}
+		}
+		if err := encoder.Close(); err != nil {
+			return fmt.Errorf("failed to encode file: %w", err)
+		}
+	}
+
+	return fs.File(file).WriteAtomic(output.Bytes(), info.Mode().Perm())
+}
+
+// encryptNode Walks a document tree and encrypts the selected leaves
+//
+// Parameters:
+// - n: *yaml.Node - the node to walk
+// - prefix: []string - the key segments leading to the node
+// - selected: map[string]bool - the normalized keys to encrypt
+// - key: []byte - the encryption key
+//
+// Returns:
+// - error: error if a value cannot be encrypted
+func encryptNode(n *yaml.Node, prefix []string, selected map[string]bool, key []byte) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, child := range n.Content {
+			if err := encryptNode(child, prefix, selected, key); err != nil {
+				return err
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if err := encryptNode(n.Content[i+1], append(prefix, n.Content[i].Value), selected, key); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, child := range n.Content {
+			if err := encryptNode(child, append(prefix, strconv.Itoa(i)), selected, key); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		if !selected[normalize.Key(strings.Join(prefix, "."))] || IsEncrypted(n.Value) {
+			return nil
+		}
+
+		encrypted, err := Encrypt(key, n.Value)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt %q: %w", strings.Join(prefix, "."), err)
+		}
+
+		n.Value, n.Tag, n.Style = encrypted, "!!str", yaml.DoubleQuotedStyle
+	}
+
+	return nil
+}
+
+// writeJSON Serializes a document tree as indented JSON
+//
+// Scalars keep their original representation, so numbers are written exactly
+// as they were read.
+//
+// Parameters:
+// - output: *bytes.Buffer - the buffer to write to
+// - n: *yaml.Node - the node to serialize
+// - indent: string - the indentation of the node
+func writeJSON(output *bytes.Buffer, n *yaml.Node, indent string) {
+	switch n.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		open, close, step := "{", "}", 2
+		if n.Kind == yaml.SequenceNode {
+			open, close, step = "[", "]", 1
+		}
+
+		output.WriteString(open)
+		for i := 0; i < len(n.Content); i += step {
+			if i > 0 {
+				output.WriteByte(',')
+			}
+			output.WriteString("\n" + indent + "  ")
+			if step == 2 {
+				name, _ := json.Marshal(n.Content[i].Value)
+				output.Write(name)
+				output.WriteString(": ")
+			}
+			writeJSON(output, n.Content[i+step-1], indent+"  ")
+		}
+		if len(n.Content) > 0 {
+			output.WriteString("\n" + indent)
+		}
+		output.WriteString(close)
+	case yaml.AliasNode:
+		writeJSON(output, n.Alias, indent)
+	default:
+		if n.Tag == "!!str" {
+			value, _ := json.Marshal(n.Value)
+			output.Write(value)
+		} else {
+			output.WriteString(n.Value)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package secret
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultIterations is the PBKDF2 iteration count of PassphraseKey.
+const defaultIterations = 600_000
+
+// KeyProvider supplies the key used to encrypt and decrypt values.
+type KeyProvider interface {
+	Key() ([]byte, error)
+}
+
+// FileKey reads the key from a local file.
+//
+// The file holds the 32 bytes key encoded in base64 or hexadecimal.
+type FileKey struct {
+	Path string
+}
+
+// Key reads and decodes the key file
+//
+// Returns:
+// - []byte: the 32 bytes key
+// - error: error if the file cannot be read or does not hold a valid key
+func (f *FileKey) Key() ([]byte, error) {
+	content, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file: %w", err)
+	}
+
+	return decodeKey(string(content))
+}
+
+// EnvKey reads the key from an environment variable.
+//
+// The variable holds the 32 bytes key encoded in base64 or hexadecimal.
+type EnvKey struct {
+	Name string
+}
+
+// Key reads and decodes the environment variable
+//
+// Returns:
+// - []byte: the 32 bytes key
+// - error: error if the variable is not set or does not hold a valid key
+func (e *EnvKey) Key() ([]byte, error) {
+	value, ok := os.LookupEnv(e.Name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", e.Name)
+	}
+
+	return decodeKey(value)
+}
+
+// PassphraseKey derives the key from a passphrase with PBKDF2-HMAC-SHA256.
+//
+// The derivation is deliberately slow, so the key is derived once and reused
+// until the passphrase, the salt or the iteration count changes.
+type PassphraseKey struct {
+	Passphrase string
+	// Salt must be the same for encryption and decryption.
+	Salt string
+	// Iterations defaults to 600000.
+	Iterations int
+
+	mu      sync.Mutex
+	derived []byte
+	params  string
+}
+
+// Key derives the key from the passphrase
+//
+// Returns:
+// - []byte: the 32 bytes key
+// - error: error if the passphrase or the salt is empty
+func (p *PassphraseKey) Key() ([]byte, error) {
+	if p.Passphrase == "" || p.Salt == "" {
+		return nil, errors.New("passphrase and salt are required")
+	}
+
+	iterations := p.Iterations
+	if iterations <= 0 {
+		iterations = defaultIterations
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	params := strconv.Quote(p.Passphrase) + strconv.Quote(p.Salt) + strconv.Itoa(iterations)
+	if p.derived == nil || p.params != params {
+		p.derived = pbkdf2([]byte(p.Passphrase), []byte(p.Salt), iterations, KeySize)
+		p.params = params
+	}
+
+	return bytes.Clone(p.derived), nil
+}
+
+// decodeKey decodes a base64 or hexadecimal encoded key.
+func decodeKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("invalid key: expected %d bytes encoded in base64 or hexadecimal", KeySize)
+}
+
+// pbkdf2 implements PBKDF2 (RFC 8018) with HMAC-SHA256.
+func pbkdf2(password, salt []byte, iterations, size int) []byte {
+	prf := hmac.New(sha256.New, password)
+	blocks := (size + prf.Size() - 1) / prf.Size()
+
+	key := make([]byte, 0, blocks*prf.Size())
+	u := make([]byte, prf.Size())
+	counter := make([]byte, 4)
+
+	for block := 1; block <= blocks; block++ {
+		binary.BigEndian.PutUint32(counter, uint32(block))
+
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(counter)
+		key = prf.Sum(key)
+
+		t := key[len(key)-prf.Size():]
+		copy(u, t)
+
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range u {
+				t[j] ^= u[j]
+			}
+		}
+	}
+
+	return key[:size]
+}
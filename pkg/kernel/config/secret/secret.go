@@ -0,0 +1,122 @@
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// KeySize is the size in bytes of the AES-256 keys used to encrypt values.
+const KeySize = 32
+
+// ErrMalformed is returned when an encrypted value cannot be parsed.
+var ErrMalformed = errors.New("malformed encrypted value")
+
+// envelope matches an encrypted value such as
+// ENC[AES256_GCM,data:...,iv:...,tag:...].
+var envelope = regexp.MustCompile(`^ENC\[AES256_GCM,data:([A-Za-z0-9+/=]*),iv:([A-Za-z0-9+/=]+),tag:([A-Za-z0-9+/=]+)\]$`)
+
+// IsEncrypted reports whether a value is an encrypted envelope
+//
+// Parameters:
+// - value: string - The configuration value to check
+//
+// Returns:
+// - bool: true if the value looks like ENC[AES256_GCM,...]
+func IsEncrypted(value string) bool {
+	return len(value) > 4 && value[:4] == "ENC["
+}
+
+// Encrypt seals a value into an AES-256-GCM envelope
+//
+// A random nonce is generated for every call, so encrypting the same value
+// twice produces two different envelopes.
+//
+// Parameters:
+// - key: []byte - The 32 bytes encryption key
+// - plaintext: string - The value to encrypt
+//
+// Returns:
+// - string: the ENC[AES256_GCM,data:...,iv:...,tag:...] envelope
+// - error: error if the key is invalid
+func Encrypt(key []byte, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	iv := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nil, iv, []byte(plaintext), nil)
+	data, tag := sealed[:len(sealed)-aead.Overhead()], sealed[len(sealed)-aead.Overhead():]
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+	), nil
+}
+
+// Decrypt opens an AES-256-GCM envelope
+//
+// Parameters:
+// - key: []byte - The 32 bytes encryption key
+// - value: string - The ENC[...] envelope
+//
+// Returns:
+// - string: the decrypted value
+// - error: error if the envelope is malformed, the key is wrong or the value
+// has been tampered with
+func Decrypt(key []byte, value string) (string, error) {
+	match := envelope.FindStringSubmatch(value)
+	if match == nil {
+		return "", ErrMalformed
+	}
+
+	parts := make([][]byte, 3)
+	for i := range parts {
+		decoded, err := base64.StdEncoding.DecodeString(match[i+1])
+		if err != nil {
+			return "", fmt.Errorf("%w: %w", ErrMalformed, err)
+		}
+		parts[i] = decoded
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	if len(iv) != aead.NonceSize() || len(tag) != aead.Overhead() {
+		return "", ErrMalformed
+	}
+
+	plaintext, err := aead.Open(nil, iv, append(data, tag...), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// newAEAD creates the AES-256-GCM cipher for a key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key size: %d bytes, expected %d", len(key), KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,193 @@
+package secret_test
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config/parser"
+	"github.com/kistunium/sdk/pkg/kernel/config/secret"
+	"github.com/stretchr/testify/assert"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncryptDecrypt(t *testing.T) {
+	encrypted, err := secret.Encrypt(testKey, "s3cr3t")
+	assert.NoError(t, err)
+	assert.True(t, secret.IsEncrypted(encrypted))
+	assert.Regexp(t, `^ENC\[AES256_GCM,data:[^,]+,iv:[^,]+,tag:[^,]+\]$`, encrypted)
+
+	again, err := secret.Encrypt(testKey, "s3cr3t")
+	assert.NoError(t, err)
+	assert.NotEqual(t, encrypted, again)
+
+	plaintext, err := secret.Decrypt(testKey, encrypted)
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", plaintext)
+}
+
+func TestDecryptErrors(t *testing.T) {
+	encrypted, err := secret.Encrypt(testKey, "s3cr3t")
+	assert.NoError(t, err)
+
+	_, err = secret.Decrypt([]byte("fedcba9876543210fedcba9876543210"), encrypted)
+	assert.Error(t, err)
+
+	_, err = secret.Decrypt(testKey, strings.Replace(encrypted, "data:", "data:AA", 1))
+	assert.Error(t, err)
+
+	_, err = secret.Decrypt(testKey, "ENC[AES256_GCM,data:abc]")
+	assert.ErrorIs(t, err, secret.ErrMalformed)
+
+	_, err = secret.Decrypt([]byte("short"), encrypted)
+	assert.Error(t, err)
+
+	assert.False(t, secret.IsEncrypted("plain"))
+}
+
+func TestKeyProviders(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	assert.NoError(t, os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(testKey)+"\n"), 0o600))
+
+	key, err := (&secret.FileKey{Path: keyFile}).Key()
+	assert.NoError(t, err)
+	assert.Equal(t, testKey, key)
+
+	t.Setenv("CONFIG_KEY", hex.EncodeToString(testKey))
+	key, err = (&secret.EnvKey{Name: "CONFIG_KEY"}).Key()
+	assert.NoError(t, err)
+	assert.Equal(t, testKey, key)
+
+	t.Setenv("CONFIG_KEY", "too short")
+	_, err = (&secret.EnvKey{Name: "CONFIG_KEY"}).Key()
+	assert.Error(t, err)
+
+	_, err = (&secret.EnvKey{Name: "CONFIG_KEY_MISSING"}).Key()
+	assert.Error(t, err)
+
+	// RFC 7914 section 11 PBKDF2-HMAC-SHA256 test vector.
+	key, err = (&secret.PassphraseKey{Passphrase: "passwd", Salt: "salt", Iterations: 1}).Key()
+	assert.NoError(t, err)
+	assert.Equal(t, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc", hex.EncodeToString(key))
+
+	_, err = (&secret.PassphraseKey{Passphrase: "passwd"}).Key()
+	assert.Error(t, err)
+}
+
+func TestPassphraseKeyCache(t *testing.T) {
+	provider := &secret.PassphraseKey{Passphrase: "passwd", Salt: "salt", Iterations: 1}
+
+	key, err := provider.Key()
+	assert.NoError(t, err)
+	key[0] ^= 0xff
+
+	again, err := provider.Key()
+	assert.NoError(t, err)
+	assert.Equal(t, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc", hex.EncodeToString(again))
+
+	provider.Salt = "other"
+	changed, err := provider.Key()
+	assert.NoError(t, err)
+	assert.NotEqual(t, again, changed)
+}
+
+func TestEncryptFileYAML(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prod.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte(strings.Join([]string{
+		"# Database settings",
+		"db:",
+		"  host: localhost # primary",
+		"  password: s3cr3t",
+		"users:",
+		"  - name: admin",
+		"    token: abc",
+		"",
+	}, "\n")), 0o640))
+
+	provider := &secret.EnvKey{Name: "CONFIG_KEY"}
+	t.Setenv("CONFIG_KEY", hex.EncodeToString(testKey))
+
+	assert.NoError(t, secret.EncryptFile(file, provider, "db.password", "users.0.token"))
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "# Database settings")
+	assert.Contains(t, string(content), "host: localhost # primary")
+	assert.NotContains(t, string(content), "s3cr3t")
+
+	info, err := os.Stat(file)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	config, err := (&parser.YAML{Path: file}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", config["db.host"])
+
+	password, err := secret.Decrypt(testKey, config["db.password"])
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", password)
+
+	token, err := secret.Decrypt(testKey, config["users.0.token"])
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", token)
+
+	// Encrypting again keeps the existing envelopes.
+	assert.NoError(t, secret.EncryptFile(file, provider, "db.password"))
+	again, err := (&parser.YAML{Path: file}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, config["db.password"], again["db.password"])
+}
+
+func TestEncryptFileYAMLMultiDocument(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prod.yaml")
+	assert.NoError(t, os.WriteFile(file, []byte("profile: dev\ndb:\n  password: dev\n---\nprofile: prod\ndb:\n  password: prod\n"), 0o600))
+
+	t.Setenv("CONFIG_KEY", hex.EncodeToString(testKey))
+	assert.NoError(t, secret.EncryptFile(file, &secret.EnvKey{Name: "CONFIG_KEY"}, "db.password"))
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(string(content), "ENC["))
+
+	for profile, expected := range map[string]string{"dev": "dev", "prod": "prod"} {
+		config, err := (&parser.YAML{Path: file, Select: "profile=" + profile}).Load()
+		assert.NoError(t, err)
+
+		password, err := secret.Decrypt(testKey, config["db.password"])
+		assert.NoError(t, err)
+		assert.Equal(t, expected, password)
+	}
+}
+
+func TestEncryptFileJSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "prod.json")
+	assert.NoError(t, os.WriteFile(file, []byte(`{"db": {"host": "localhost", "port": 5432, "password": "s3cr3t"}, "ids": [12345678901234567890], "debug": false}`), 0o600))
+
+	t.Setenv("CONFIG_KEY", hex.EncodeToString(testKey))
+	assert.NoError(t, secret.EncryptFile(file, &secret.EnvKey{Name: "CONFIG_KEY"}, "db.password"))
+
+	content, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), `"port": 5432`)
+	assert.Contains(t, string(content), "12345678901234567890")
+
+	config, err := (&parser.JSON{Path: file}).Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "localhost", config["db.host"])
+	assert.Equal(t, "false", config["debug"])
+
+	password, err := secret.Decrypt(testKey, config["db.password"])
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", password)
+}
+
+func TestEncryptFileUnsupported(t *testing.T) {
+	for _, file := range []string{"config.xml", ".env"} {
+		err := secret.EncryptFile(file, &secret.EnvKey{Name: "CONFIG_KEY"}, "db.password")
+		assert.ErrorContains(t, err, "only YAML and JSON files can be encrypted in place")
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"maps"
+	"slices"
+)
+
+// Snapshot is an immutable view of the configuration at a point in time.
+//
+// A Snapshot is never modified once it has been published, so it can be read
+// concurrently without any locking. Every write on a Config produces a new
+// Snapshot instead of mutating the current one.
+type Snapshot struct {
+	data    map[string]any
+	sources map[string]string
+	secrets map[string]bool
+	// patterns identify the secret keys, DefaultSecretPatterns when empty.
+	patterns []string
+	// deprecations redirect the reads of deprecated keys to their replacement,
+	// reporting each read to onDeprecated, see Config.RegisterAlias.
+	deprecations map[string]Deprecation
+	onDeprecated func(key, old string, deprecation Deprecation)
+}
+
+// emptySnapshot is shared by every Config that has not been written to yet.
+var emptySnapshot = &Snapshot{data: map[string]any{}, sources: map[string]string{}, secrets: map[string]bool{}}
+
+// Viewer is implemented by anything able to expose a configuration snapshot,
+// such as a Config or a Snapshot itself.
+type Viewer interface {
+	Snapshot() *Snapshot
+}
+
+// Collect loads a set of parsers into a standalone snapshot
+//
+// The parsers are applied in order, exactly as Config.Load would, without
+// creating a long lived Config.
+//
+// Parameters:
+// - parsers: ...Parser - The parsers to load, lowest priority first
+//
+// Returns:
+// - *Snapshot: the loaded configuration
+// - error: error if any parser fails to load
+func Collect(parsers ...Parser) (*Snapshot, error) {
+	c := New(parsers...)
+	if err := c.Load(); err != nil {
+		return nil, err
+	}
+
+	return c.Snapshot(), nil
+}
+
+// Snapshot returns the snapshot itself so that it satisfies Viewer
+//
+// Returns:
+// - *Snapshot: the receiver
+func (s *Snapshot) Snapshot() *Snapshot {
+	return s
+}
+
+// Get retrieves a value from the snapshot
+//
+// A deprecated key registered with Config.RegisterAlias is read from its
+// replacement.
+//
+// Parameters:
+// - key: string - The configuration key to retrieve
+// - defaultValue: any - The value returned when the key is not present
+//
+// Returns:
+// - value: any - The configuration value
+func (s *Snapshot) Get(key string, defaultValue any) any {
+	if value, ok := s.data[s.resolve(key)]; ok {
+		return value
+	}
+
+	return defaultValue
+}
+
+// Has reports whether the key is present in the snapshot
+//
+// Parameters:
+// - key: string - The configuration key to look up
+//
+// Returns:
+// - bool: true if the key is present, false otherwise
+func (s *Snapshot) Has(key string) bool {
+	_, ok := s.data[s.resolve(key)]
+	return ok
+}
+
+// Source returns the provenance of a key
+//
+// The provenance is the Type of the parser that provided the value, "set"
+// when the value was written at runtime with Config.Set, or "default" when the
+// value is the default declared with Config.SetDefault or Config.Declare.
+//
+// Parameters:
+// - key: string - The configuration key to look up
+//
+// Returns:
+// - string: the source of the value, empty if the key is not present
+func (s *Snapshot) Source(key string) string {
+	return s.sources[s.resolve(key)]
+}
+
+// Secret reports whether the value of a key must not be displayed
+//
+// Parameters:
+// - key: string - The configuration key to check
+//
+// Returns:
+// - bool: true if the key matches the secret patterns of the Config, see
+// Config.SetSecretPatterns, or its value was encrypted in its source
+func (s *Snapshot) Secret(key string) bool {
+	return s.secrets[key] || IsSecret(key, s.patterns...)
+}
+
+// Keys returns every key of the snapshot in lexical order
+//
+// Returns:
+// - []string: the sorted configuration keys
+func (s *Snapshot) Keys() []string {
+	return slices.Sorted(maps.Keys(s.data))
+}
+
+// Len returns the number of keys in the snapshot
+//
+// Returns:
+// - int: the number of configuration keys
+func (s *Snapshot) Len() int {
+	return len(s.data)
+}
+
+// Map returns a copy of the snapshot content
+//
+// The returned map belongs to the caller and can be modified freely.
+//
+// Returns:
+// - map[string]any: a copy of the configuration values
+func (s *Snapshot) Map() map[string]any {
+	return maps.Clone(s.data)
+}
+
+// clone returns a private copy of the snapshot to build the next one from.
+func (s *Snapshot) clone() *Snapshot {
+	return &Snapshot{
+		data:     maps.Clone(s.data),
+		sources:  maps.Clone(s.sources),
+		secrets:  maps.Clone(s.secrets),
+		patterns: s.patterns,
+
+		deprecations: s.deprecations,
+		onDeprecated: s.onDeprecated,
+	}
+}
+
+// resolve Returns the key serving the values of a key
+//
+// Parameters:
+// - key: string - The key read, possibly deprecated
+//
+// Returns:
+// - string: the replacement of a deprecated key, the key itself otherwise
+func (s *Snapshot) resolve(key string) string {
+	if len(s.deprecations) == 0 {
+		return key
+	}
+
+	old, deprecation, ok := deprecated(s.deprecations, key)
+	if !ok {
+		return key
+	}
+
+	if s.onDeprecated != nil {
+		s.onDeprecated(key, old, deprecation)
+	}
+
+	if deprecation.Replacement == "" {
+		return key
+	}
+
+	return deprecation.Replacement + key[len(old):]
+}
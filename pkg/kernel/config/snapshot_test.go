@@ -0,0 +1,75 @@
+package config_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticParser map[string]string
+
+func (p staticParser) Load() (map[string]string, error) { return p, nil }
+func (p staticParser) Type() string                     { return "static" }
+
+func TestSnapshotIsImmutable(t *testing.T) {
+	c := config.New(staticParser{"db.host": "localhost", "db.port": "5432"})
+	assert.NoError(t, c.Load())
+
+	snapshot := c.Snapshot()
+	c.Set("db.host", "remote")
+	c.Set("db.user", "admin")
+
+	assert.Equal(t, "localhost", snapshot.Get("db.host", nil))
+	assert.False(t, snapshot.Has("db.user"))
+	assert.Equal(t, []string{"db.host", "db.port"}, snapshot.Keys())
+	assert.Equal(t, 2, snapshot.Len())
+
+	assert.Equal(t, "remote", c.Get("db.host", nil))
+	assert.Equal(t, 3, c.Snapshot().Len())
+}
+
+func TestSnapshotMapIsACopy(t *testing.T) {
+	c := config.New()
+	c.Set("key", "value")
+
+	data := c.Snapshot().Map()
+	data["key"] = "changed"
+
+	assert.Equal(t, "value", c.Get("key", nil))
+}
+
+func TestSnapshotOfEmptyConfig(t *testing.T) {
+	var c config.Config
+
+	assert.Equal(t, 0, c.Snapshot().Len())
+	assert.Equal(t, "default", c.Get("missing", "default"))
+}
+
+func TestSnapshotConsistentDuringLoad(t *testing.T) {
+	c := config.New(staticParser{"a": "1", "b": "1"})
+	assert.NoError(t, c.Load())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 2; i < 200; i++ {
+			v := strconv.Itoa(i)
+			c.Set("a", v)
+			c.Set("b", v)
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		s := c.Snapshot()
+		a, b := s.Get("a", nil).(string), s.Get("b", nil).(string)
+		ai, _ := strconv.Atoi(a)
+		bi, _ := strconv.Atoi(b)
+		assert.True(t, ai == bi || ai == bi+1, "a=%s b=%s", a, b)
+	}
+
+	wg.Wait()
+}
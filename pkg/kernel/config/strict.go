@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// defaultStrictSources lists the parser types checked by default in strict
+// mode: the file parsers, whose keys are all meant for the application.
+var defaultStrictSources = []string{"json", "yaml", "xml"}
+
+// Strict configures the detection of unknown keys.
+//
+// A key is known when it is declared with Declare, SetDefault or by a schema,
+// is deprecated (see Deprecate), or matches Known.
+type Strict struct {
+	// Fail makes Load return an error on unknown keys. They are only logged
+	// otherwise.
+	Fail bool
+	// Sources lists the parser types checked, "json", "yaml" and "xml" when
+	// empty. Sources such as "env" or "args" hold unrelated keys and are
+	// excluded by default.
+	Sources []string
+	// Known lists additional known keys. A key ending with ".*" accepts every
+	// key nested in it, e.g. "plugins.*".
+	Known []string
+}
+
+// UnknownKeyError describes a key loaded in strict mode that is not known.
+type UnknownKeyError struct {
+	Key        string `json:"key"`
+	Source     string `json:"source"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *UnknownKeyError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("unknown key %q from %s, did you mean %q?", e.Key, e.Source, e.Suggestion)
+	}
+
+	return fmt.Sprintf("unknown key %q from %s", e.Key, e.Source)
+}
+
+// SetStrict enables the detection of unknown keys, disabled when nil
+//
+// Parameters:
+// - strict: *Strict - The known keys and the sources to check
+func (c *Config) SetStrict(strict *Strict) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.strict = strict
+}
+
+// unknown Checks the loaded keys against the known ones
+//
+// In warning mode, each unknown key is logged once and nil is returned.
+//
+// Parameters:
+// - loaded: []map[string]string - The values returned by the parsers, aliases
+// resolved
+//
+// Returns:
+// - error: the *UnknownKeyError of every unknown key, joined, in failing mode
+func (c *Config) unknown(loaded []map[string]string) error {
+	c.mu.Lock()
+	strict := c.strict
+	known := maps.Clone(c.specs)
+	deprecations := maps.Clone(c.deprecations)
+	c.mu.Unlock()
+
+	if strict == nil {
+		return nil
+	}
+
+	sources := strict.Sources
+	if len(sources) == 0 {
+		sources = defaultStrictSources
+	}
+
+	candidates := slices.Collect(maps.Keys(known))
+	var prefixes []string
+	for _, key := range strict.Known {
+		if prefix, ok := strings.CutSuffix(key, ".*"); ok {
+			prefixes = append(prefixes, prefix+".")
+			continue
+		}
+		candidates = append(candidates, key)
+	}
+	slices.Sort(candidates)
+
+	var errs []error
+	for i, data := range loaded {
+		source := c.parsers[i].Type()
+		if !slices.Contains(sources, source) {
+			continue
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(data)) {
+			if _, ok := slices.BinarySearch(candidates, key); ok {
+				continue
+			}
+			if _, _, ok := deprecated(deprecations, key); ok {
+				continue
+			}
+			if slices.ContainsFunc(prefixes, func(prefix string) bool { return strings.HasPrefix(key, prefix) }) {
+				continue
+			}
+
+			err := &UnknownKeyError{Key: key, Source: source, Suggestion: suggest(key, candidates)}
+			if strict.Fail {
+				errs = append(errs, err)
+			} else if c.warnOnce("unknown:" + key) {
+				args := []any{"key", key, "source", source}
+				if err.Suggestion != "" {
+					args = append(args, "suggestion", err.Suggestion)
+				}
+				c.warn("unknown configuration key", args...)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// suggest Returns the candidate closest to a key by edit distance, empty when
+// none is close enough to be a typo
+func suggest(key string, candidates []string) string {
+	best, distance := "", len(key)/3+1
+	for _, candidate := range candidates {
+		if d := levenshtein(key, candidate); d <= distance && (best == "" || d < distance) {
+			best, distance = candidate, d
+		}
+	}
+
+	return best
+}
+
+// levenshtein Returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
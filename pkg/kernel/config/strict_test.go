@@ -0,0 +1,59 @@
+package config_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// typedParser is a staticParser with a configurable type.
+type typedParser struct {
+	typ  string
+	data map[string]string
+}
+
+func (p typedParser) Load() (map[string]string, error) { return p.data, nil }
+func (p typedParser) Type() string                     { return p.typ }
+
+func TestStrictFail(t *testing.T) {
+	c := config.New(
+		typedParser{"env", map[string]string{"path": "/usr/bin", "home": "/root"}},
+		typedParser{"yaml", map[string]string{"databse.host": "db.local", "database.port": "5432", "plugins.x.enabled": "true", "zzz": "1"}},
+	)
+	c.Declare(config.Spec{Key: "database.host"}, config.Spec{Key: "database.port"})
+	c.SetStrict(&config.Strict{Fail: true, Known: []string{"plugins.*"}})
+
+	err := c.Load()
+	assert.Error(t, err)
+	assert.Equal(t, 0, c.Snapshot().Len())
+
+	var unknown *config.UnknownKeyError
+	assert.True(t, errors.As(err, &unknown))
+	assert.Equal(t, config.UnknownKeyError{Key: "databse.host", Source: "yaml", Suggestion: "database.host"}, *unknown)
+	assert.EqualError(t, err, "unknown key \"databse.host\" from yaml, did you mean \"database.host\"?\nunknown key \"zzz\" from yaml")
+
+	c.SetStrict(&config.Strict{Fail: true, Sources: []string{"yaml", "env"}, Known: []string{"plugins.*", "databse.host", "zzz"}})
+	assert.ErrorContains(t, c.Load(), `unknown key "home" from env`)
+
+	c.SetStrict(nil)
+	assert.NoError(t, c.Load())
+}
+
+func TestStrictWarn(t *testing.T) {
+	logger := &recordingLogger{}
+	c := config.New(typedParser{"json", map[string]string{"db.hots": "x", "db.old": "y"}})
+	c.SetLogger(logger)
+	c.Declare(config.Spec{Key: "db.host"})
+	c.Deprecate("db.old", config.Deprecation{})
+	c.SetStrict(&config.Strict{})
+
+	assert.NoError(t, c.Load())
+	assert.NoError(t, c.Load())
+	assert.Equal(t, "x", c.Get("db.hots", nil))
+	assert.Equal(t, [][]any{
+		{"deprecated configuration key", "key", "db.old", "source", "json"},
+		{"unknown configuration key", "key", "db.hots", "source", "json", "suggestion", "db.host"},
+	}, logger.warnings)
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Watcher is implemented by parsers able to notify configuration changes.
+type Watcher interface {
+	// Watch blocks until the context is done, calling changed each time the
+	// source content changes.
+	Watch(ctx context.Context, changed func()) error
+}
+
+// Watch reloads the configuration whenever a watching parser reports a change
+//
+// Every parser implementing Watcher is watched concurrently. A change reloads
+// all the parsers so that priorities are preserved; a failed reload keeps the
+// previous configuration. The call blocks until the context is done or a
+// watcher fails.
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the watch
+//
+// Returns:
+// - error: the first watcher error, or the context error once watching stops
+func (c *Config) Watch(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+
+	for _, parser := range c.parsers {
+		watcher, ok := parser.(Watcher)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := watcher.Watch(ctx, func() { _ = c.Load() })
+			if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+				once.Do(func() { first = err })
+				cancel()
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+
+	if first != nil {
+		return first
+	}
+
+	return ctx.Err()
+}
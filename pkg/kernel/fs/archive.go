@@ -0,0 +1,601 @@
+package fs
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ArchiveFormat is the format of an archive.
+type ArchiveFormat string
+
+const (
+	// Tar is an uncompressed tar archive.
+	Tar ArchiveFormat = "tar"
+	// TarGz is a gzip compressed tar archive.
+	TarGz ArchiveFormat = "tar.gz"
+	// Zip is a zip archive, compressed with deflate.
+	Zip ArchiveFormat = "zip"
+)
+
+// ErrArchiveLimit is returned when an archive exceeds the limits of Extract.
+var ErrArchiveLimit = errors.New("archive exceeds limits")
+
+const (
+	// defaultArchiveMaxSize is the default limit of the extracted size.
+	defaultArchiveMaxSize = 1 << 30
+	// defaultArchiveMaxEntries is the default limit of the extracted entries.
+	defaultArchiveMaxEntries = 10000
+)
+
+// ArchiveFormatOf returns the format of an archive from its extension
+//
+// Parameters:
+// - name: string - the name of the archive, e.g. "config.tar.gz"
+//
+// Returns:
+// - ArchiveFormat: the format of the archive
+// - error: error if the extension is not supported
+func ArchiveFormatOf(name string) (ArchiveFormat, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return TarGz, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return Tar, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return Zip, nil
+	}
+
+	return "", fmt.Errorf("unsupported archive format: %s", filepath.Ext(name))
+}
+
+// ArchiveOptions configures Directory.Archive.
+type ArchiveOptions struct {
+	// Include keeps only the files whose base name matches one of the
+	// filepath.Match patterns, e.g. "*.yaml". Every file is kept when empty.
+	Include []string
+	// Exclude drops the files and directories whose base name matches one of
+	// the patterns, e.g. ".git".
+	Exclude []string
+	// ModTime replaces the modification time of every entry, for reproducible
+	// archives. The times of the files are kept when zero.
+	ModTime time.Time
+}
+
+// ExtractOptions configures Extract.
+type ExtractOptions struct {
+	// MaxSize limits the total size of the extracted files in bytes. Defaults
+	// to 1 GiB.
+	MaxSize int64
+	// MaxEntries limits the number of extracted entries. Defaults to 10000.
+	MaxEntries int
+}
+
+// archiveEntry is a file, directory or symbolic link of an archive.
+type archiveEntry struct {
+	name    string
+	mode    os.FileMode
+	modTime time.Time
+	size    int64
+	link    string
+}
+
+// Archive writes the content of the directory as an archive
+//
+// Entries are written sorted by path, relative to the directory, with their
+// permissions, modification times truncated to the second and symbolic links.
+// Owners are not stored, so that the same content always gives the same
+// archive.
+//
+// Parameters:
+// - w: io.Writer - the destination of the archive
+// - format: ArchiveFormat - the format of the archive
+// - options: ArchiveOptions - the filters and the normalized modification time
+//
+// Returns:
+// - error: error if the directory cannot be read or the archive written
+func (d Directory) Archive(w io.Writer, format ArchiveFormat, options ArchiveOptions) error {
+	var add func(archiveEntry, io.Reader) error
+	var closers []io.Closer
+
+	switch format {
+	case Tar, TarGz:
+		if format == TarGz {
+			compressed := gzip.NewWriter(w)
+			closers = append(closers, compressed)
+			w = compressed
+		}
+
+		writer := tar.NewWriter(w)
+		closers = append([]io.Closer{writer}, closers...)
+		add = func(entry archiveEntry, content io.Reader) error {
+			return writeTarEntry(writer, entry, content)
+		}
+	case Zip:
+		writer := zip.NewWriter(w)
+		closers = append(closers, writer)
+		add = func(entry archiveEntry, content io.Reader) error {
+			return writeZipEntry(writer, entry, content)
+		}
+	default:
+		return fmt.Errorf("unsupported archive format: %s", format)
+	}
+
+	err := filepath.WalkDir(string(d), func(name string, dirEntry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if name == string(d) {
+			return nil
+		}
+
+		if matchAny(dirEntry.Name(), options.Exclude) {
+			if dirEntry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !dirEntry.IsDir() && len(options.Include) > 0 && !matchAny(dirEntry.Name(), options.Include) {
+			return nil
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(string(d), name)
+		if err != nil {
+			return err
+		}
+
+		entry := archiveEntry{name: filepath.ToSlash(rel), mode: info.Mode(), modTime: info.ModTime()}
+		if !options.ModTime.IsZero() {
+			entry.modTime = options.ModTime
+		}
+		entry.modTime = entry.modTime.Truncate(time.Second)
+
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			if entry.link, err = os.Readlink(name); err != nil {
+				return err
+			}
+			return add(entry, nil)
+		case info.IsDir():
+			return add(entry, nil)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+
+		file, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		entry.size = info.Size()
+
+		return add(entry, file)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive directory: %w", err)
+	}
+
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return fmt.Errorf("failed to archive directory: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Extract extracts an archive into a directory
+//
+// Permissions, modification times and symbolic links are restored, owners are
+// not. Entries leading outside of the directory, by their name, a symbolic link
+// or a link target, are rejected with an error matching ErrOutsideRoot. Link
+// targets going through another symbolic link are rejected too. The extracted
+// size and entry count are limited, whatever the archive claims, and an error
+// matching ErrArchiveLimit is returned when they are exceeded.
+//
+// Parameters:
+// - r: io.Reader - the archive
+// - dest: Directory - the directory receiving the content, created if missing
+// - format: ArchiveFormat - the format of the archive
+// - options: ExtractOptions - the limits of the extraction
+//
+// Returns:
+// - error: error if the archive is invalid or cannot be extracted
+func Extract(r io.Reader, dest Directory, format ArchiveFormat, options ExtractOptions) error {
+	if options.MaxSize <= 0 {
+		options.MaxSize = defaultArchiveMaxSize
+	}
+	if options.MaxEntries <= 0 {
+		options.MaxEntries = defaultArchiveMaxEntries
+	}
+
+	if err := dest.MkdirAll(0o755); err != nil {
+		return fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	x := &extractor{dest: dest, options: options}
+
+	var err error
+	switch format {
+	case Tar:
+		err = x.tar(r)
+	case TarGz:
+		var compressed *gzip.Reader
+		if compressed, err = gzip.NewReader(r); err == nil {
+			err = x.tar(compressed)
+		}
+	case Zip:
+		err = x.zip(r)
+	default:
+		err = fmt.Errorf("unsupported archive format: %s", format)
+	}
+
+	if err == nil {
+		err = x.finish()
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	return nil
+}
+
+// extractor holds the state of an extraction.
+type extractor struct {
+	dest    Directory
+	options ExtractOptions
+	size    int64
+	entries int
+	dirs    []archiveEntry
+	// traversed holds the paths the targets of the extracted symbolic links go
+	// through, relative to dest, so that no symbolic link is created there
+	// later on.
+	traversed map[string]bool
+}
+
+// tar Extracts the entries of a tar archive
+func (x *extractor) tar(r io.Reader) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		entry := archiveEntry{
+			name:    header.Name,
+			mode:    os.FileMode(header.Mode).Perm(),
+			modTime: header.ModTime,
+			link:    header.Linkname,
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+		case tar.TypeDir:
+			entry.mode |= os.ModeDir
+		case tar.TypeSymlink:
+			entry.mode |= os.ModeSymlink
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			return fmt.Errorf("unsupported entry type %q: %s", header.Typeflag, header.Name)
+		}
+
+		if err := x.extract(entry, reader); err != nil {
+			return err
+		}
+	}
+}
+
+// zip Extracts the entries of a zip archive, buffered to a temporary file
+func (x *extractor) zip(r io.Reader) error {
+	buffer, err := TempFile("", "extract-*.zip")
+	if err != nil {
+		return err
+	}
+	defer buffer.Cleanup()
+
+	file, err := os.OpenFile(string(buffer), os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	size, err := io.Copy(file, io.LimitReader(r, x.options.MaxSize+1))
+	if err != nil {
+		return err
+	}
+
+	if size > x.options.MaxSize {
+		return ErrArchiveLimit
+	}
+
+	reader, err := zip.NewReader(file, size)
+	if err != nil {
+		return err
+	}
+
+	for _, header := range reader.File {
+		entry := archiveEntry{name: header.Name, mode: header.Mode(), modTime: header.Modified}
+		if !entry.mode.IsDir() && entry.mode&os.ModeSymlink == 0 {
+			entry.mode = entry.mode.Perm()
+		}
+
+		if err := x.extractZip(entry, header); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractZip Extracts an entry of a zip archive
+func (x *extractor) extractZip(entry archiveEntry, header *zip.File) error {
+	content, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if entry.mode&os.ModeSymlink != 0 {
+		target, err := io.ReadAll(io.LimitReader(content, 4096))
+		if err != nil {
+			return err
+		}
+		entry.link = string(target)
+	}
+
+	return x.extract(entry, content)
+}
+
+// extract Extracts an entry into the destination directory
+func (x *extractor) extract(entry archiveEntry, content io.Reader) error {
+	if x.entries++; x.entries > x.options.MaxEntries {
+		return ErrArchiveLimit
+	}
+
+	rel, err := rootRelative("extract", strings.TrimSuffix(entry.name, "/"))
+	if err != nil {
+		return err
+	}
+
+	target, err := x.dest.SafeJoin(rel)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case entry.mode.IsDir():
+		if err := Directory(target).MkdirAll(0o700); err != nil {
+			return err
+		}
+		entry.name = string(target)
+		x.dirs = append(x.dirs, entry)
+
+		return nil
+	case entry.mode&os.ModeSymlink != 0:
+		link := filepath.FromSlash(entry.link)
+		if err := x.checkLink(rel, link); err != nil {
+			return &os.LinkError{Op: "symlink", Old: entry.link, New: entry.name, Err: err}
+		}
+
+		if err := target.Dir().MkdirAll(0o755); err != nil {
+			return err
+		}
+
+		if err := target.Remove(); err != nil {
+			return err
+		}
+
+		return os.Symlink(link, string(target))
+	}
+
+	if err := target.Dir().MkdirAll(0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(string(target), os.O_WRONLY|os.O_CREATE|os.O_TRUNC|noFollow, 0o600)
+	if err != nil {
+		return err
+	}
+
+	remaining := x.options.MaxSize - x.size
+	written, err := io.Copy(file, io.LimitReader(content, remaining+1))
+	x.size += written
+	if err == nil && written > remaining {
+		err = ErrArchiveLimit
+	}
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Chmod(entry.mode.Perm()); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(string(target), entry.modTime, entry.modTime)
+}
+
+// checkLink Checks that the target of a symbolic link stays in the destination
+//
+// The target is resolved one element at a time from the directory of the link.
+// A lexical check is not enough once symbolic links are extracted: with
+// "d/up -> ..", the target "d/up/.." leads out of the destination. So a target
+// going through a symbolic link, or a link created where an earlier target goes
+// through, is rejected, and a target naming a symbolic link must resolve inside
+// of the destination.
+//
+// Parameters:
+// - rel: string - the path of the link, relative to the destination
+// - link: string - the target of the link
+//
+// Returns:
+// - error: ErrOutsideRoot if the target can lead outside of the destination
+func (x *extractor) checkLink(rel, link string) error {
+	if isAbs(link) || x.traversed[rel] {
+		return ErrOutsideRoot
+	}
+
+	// The path is not cleaned, ".." must apply to the element it follows.
+	var elems []string
+	for _, elem := range strings.Split(filepath.Dir(rel)+string(filepath.Separator)+link, string(filepath.Separator)) {
+		if elem != "" && elem != "." {
+			elems = append(elems, elem)
+		}
+	}
+
+	var resolved, traversed []string
+	for i, elem := range elems {
+		if elem == ".." {
+			if len(resolved) == 0 {
+				return ErrOutsideRoot
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+
+		resolved = append(resolved, elem)
+		current := filepath.Join(resolved...)
+
+		info, err := os.Lstat(string(x.dest.Join(current)))
+		if err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if i < len(elems)-1 {
+				return ErrOutsideRoot
+			}
+
+			if _, err := x.dest.SafeJoin(current); err != nil {
+				return ErrOutsideRoot
+			}
+		}
+
+		if i < len(elems)-1 {
+			traversed = append(traversed, current)
+		}
+	}
+
+	if x.traversed == nil {
+		x.traversed = map[string]bool{}
+	}
+	for _, path := range traversed {
+		x.traversed[path] = true
+	}
+
+	return nil
+}
+
+// finish Restores the permissions and times of the directories, deepest
+// first, once their content is extracted
+func (x *extractor) finish() error {
+	for i := len(x.dirs) - 1; i >= 0; i-- {
+		dir := x.dirs[i]
+		if err := os.Chmod(dir.name, dir.mode.Perm()); err != nil {
+			return err
+		}
+
+		if err := os.Chtimes(dir.name, dir.modTime, dir.modTime); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeTarEntry Writes an entry to a tar archive
+func writeTarEntry(writer *tar.Writer, entry archiveEntry, content io.Reader) error {
+	header := &tar.Header{
+		Name:     entry.name,
+		Mode:     int64(entry.mode.Perm()),
+		ModTime:  entry.modTime,
+		Typeflag: tar.TypeReg,
+		Size:     entry.size,
+		Format:   tar.FormatPAX,
+	}
+
+	switch {
+	case entry.mode.IsDir():
+		header.Name += "/"
+		header.Typeflag = tar.TypeDir
+	case entry.mode&os.ModeSymlink != 0:
+		header.Typeflag = tar.TypeSymlink
+		header.Linkname = filepath.ToSlash(entry.link)
+	}
+
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+
+	if content == nil {
+		return nil
+	}
+
+	_, err := io.CopyN(writer, content, entry.size)
+
+	return err
+}
+
+// writeZipEntry Writes an entry to a zip archive
+func writeZipEntry(writer *zip.Writer, entry archiveEntry, content io.Reader) error {
+	header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: entry.modTime.UTC()}
+	header.SetMode(entry.mode)
+
+	switch {
+	case entry.mode.IsDir():
+		header.Name += "/"
+		header.Method = zip.Store
+	case entry.mode&os.ModeSymlink != 0:
+		content = strings.NewReader(filepath.ToSlash(entry.link))
+	}
+
+	output, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	if content == nil {
+		return nil
+	}
+
+	_, err = io.Copy(output, content)
+
+	return err
+}
+
+// matchAny Reports whether a base name matches one of the filepath.Match
+// patterns
+func matchAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+
+	return false
+}
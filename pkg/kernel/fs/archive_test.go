@@ -0,0 +1,132 @@
+package fs_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	source := fs.Directory(t.TempDir())
+	assert.NoError(t, source.Sub("conf").MkdirAll(0o755))
+	assert.NoError(t, source.Sub(".git").MkdirAll(0o755))
+	assert.NoError(t, os.WriteFile(string(source.Join("conf", "app.yaml")), []byte("a: 1\n"), 0o640))
+	assert.NoError(t, os.WriteFile(string(source.Join("conf", "app.yaml~")), nil, 0o600))
+	assert.NoError(t, os.WriteFile(string(source.Join(".git", "HEAD")), nil, 0o600))
+	assert.NoError(t, os.Symlink("conf/app.yaml", string(source.Join("current.yaml"))))
+
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	options := fs.ArchiveOptions{Exclude: []string{".git", "*~"}, ModTime: modTime}
+
+	for _, format := range []fs.ArchiveFormat{fs.Tar, fs.TarGz, fs.Zip} {
+		t.Run(string(format), func(t *testing.T) {
+			var first, second bytes.Buffer
+			assert.NoError(t, source.Archive(&first, format, options))
+			assert.NoError(t, os.Chtimes(string(source.Join("conf", "app.yaml")), time.Now(), time.Now()))
+			assert.NoError(t, source.Archive(&second, format, options))
+			assert.Equal(t, first.Bytes(), second.Bytes())
+
+			dest := fs.Directory(t.TempDir()).Sub("out")
+			assert.NoError(t, fs.Extract(&first, dest, format, fs.ExtractOptions{}))
+
+			content, err := dest.Join("current.yaml").ReadAll()
+			assert.NoError(t, err)
+			assert.Equal(t, "a: 1\n", string(content))
+
+			info, err := dest.Join("conf", "app.yaml").Stat()
+			assert.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+			assert.True(t, modTime.Equal(info.ModTime()))
+
+			for _, name := range []string{".git", "conf/app.yaml~"} {
+				_, err := os.Lstat(string(dest.Join(name)))
+				assert.ErrorIs(t, err, os.ErrNotExist, name)
+			}
+		})
+	}
+}
+
+func TestExtractUnsafe(t *testing.T) {
+	for name, headers := range map[string][]*tar.Header{
+		"parent":   {{Name: "../evil", Typeflag: tar.TypeReg, Mode: 0o644}},
+		"absolute": {{Name: "/tmp/evil", Typeflag: tar.TypeReg, Mode: 0o644}},
+		"symlink":  {{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "../../etc"}},
+		"through": {
+			{Name: "dir", Typeflag: tar.TypeSymlink, Linkname: "."},
+			{Name: "dir/../../evil", Typeflag: tar.TypeReg, Mode: 0o644},
+		},
+		"chained": {
+			{Name: "d/up", Typeflag: tar.TypeSymlink, Linkname: ".."},
+			{Name: "esc", Typeflag: tar.TypeSymlink, Linkname: "d/up/.."},
+		},
+		"chained before": {
+			{Name: "esc", Typeflag: tar.TypeSymlink, Linkname: "d/up/.."},
+			{Name: "d/up", Typeflag: tar.TypeSymlink, Linkname: ".."},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var archive bytes.Buffer
+			writer := tar.NewWriter(&archive)
+			for _, header := range headers {
+				assert.NoError(t, writer.WriteHeader(header))
+			}
+			assert.NoError(t, writer.Close())
+
+			err := fs.Extract(&archive, fs.Directory(t.TempDir()), fs.Tar, fs.ExtractOptions{})
+			assert.ErrorIs(t, err, fs.ErrOutsideRoot)
+		})
+	}
+}
+
+func TestExtractLinkToLink(t *testing.T) {
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	for _, header := range []*tar.Header{
+		{Name: "conf/", Typeflag: tar.TypeDir, Mode: 0o755},
+		{Name: "current", Typeflag: tar.TypeSymlink, Linkname: "conf"},
+		{Name: "latest", Typeflag: tar.TypeSymlink, Linkname: "current"},
+	} {
+		assert.NoError(t, writer.WriteHeader(header))
+	}
+	assert.NoError(t, writer.Close())
+
+	dest := fs.Directory(t.TempDir())
+	assert.NoError(t, fs.Extract(&archive, dest, fs.Tar, fs.ExtractOptions{}))
+
+	target, err := os.Readlink(string(dest.Join("latest")))
+	assert.NoError(t, err)
+	assert.Equal(t, "current", target)
+}
+
+func TestExtractLimits(t *testing.T) {
+	source := fs.Directory(t.TempDir())
+	for _, name := range []string{"a", "b", "c"} {
+		assert.NoError(t, os.WriteFile(string(source.Join(name)), bytes.Repeat([]byte("x"), 1024), 0o600))
+	}
+
+	var archive bytes.Buffer
+	assert.NoError(t, source.Archive(&archive, fs.TarGz, fs.ArchiveOptions{}))
+
+	for _, options := range []fs.ExtractOptions{{MaxSize: 2048}, {MaxEntries: 2}} {
+		err := fs.Extract(bytes.NewReader(archive.Bytes()), fs.Directory(t.TempDir()), fs.TarGz, options)
+		assert.ErrorIs(t, err, fs.ErrArchiveLimit)
+	}
+
+	assert.NoError(t, fs.Extract(bytes.NewReader(archive.Bytes()), fs.Directory(t.TempDir()), fs.TarGz, fs.ExtractOptions{MaxSize: 3072}))
+}
+
+func TestArchiveFormatOf(t *testing.T) {
+	for name, expected := range map[string]fs.ArchiveFormat{"a.tar": fs.Tar, "a.TGZ": fs.TarGz, "a.tar.gz": fs.TarGz, "a.zip": fs.Zip} {
+		format, err := fs.ArchiveFormatOf(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, format)
+	}
+
+	_, err := fs.ArchiveFormatOf("a.rar")
+	assert.Error(t, err)
+}
@@ -0,0 +1,170 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Owner is the owner of a file.
+type Owner struct {
+	UID int
+	GID int
+}
+
+// AtomicOptions configures an AtomicWriter.
+type AtomicOptions struct {
+	// Perm is the permissions of the file. When zero, the permissions of the
+	// previous version are kept, 0644 for a new file.
+	Perm os.FileMode
+	// Owner sets the owner of the file. When nil, the owner of the previous
+	// version is kept when the process is allowed to.
+	Owner *Owner
+	// Backup is the suffix of the copy of the previous version, e.g. ".bak".
+	// No copy is kept when empty.
+	Backup string
+}
+
+// AtomicWriter writes the content of a file, replacing it only once the new
+// content is complete and durable.
+//
+// The content is written to a temporary file of the same directory. Commit
+// syncs it to the disk, sets its permissions and owner, renames it over the
+// file and syncs the directory, so that after a crash the file holds either
+// the previous or the new content, never a partial one. Close discards the
+// content when Commit has not been called, so that it can be deferred.
+type AtomicWriter struct {
+	file    File
+	options AtomicOptions
+	temp    *os.File
+	done    bool
+}
+
+// NewAtomicWriter starts an atomic write of a file
+//
+// Parameters:
+// - file: File - the file to replace, whose directory must exist
+// - options: AtomicOptions - the permissions, owner and backup of the file
+//
+// Returns:
+// - *AtomicWriter: the writer, to be committed or closed by the caller
+// - error: error if the temporary file cannot be created
+func NewAtomicWriter(file File, options AtomicOptions) (*AtomicWriter, error) {
+	temp, err := os.CreateTemp(string(file.Dir()), "."+file.Base()+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return &AtomicWriter{file: file, options: options, temp: temp}, nil
+}
+
+// Write implements io.Writer.
+func (w *AtomicWriter) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, fmt.Errorf("failed to write file: %w", os.ErrClosed)
+	}
+
+	n, err := w.temp.Write(p)
+	if err != nil {
+		return n, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return n, nil
+}
+
+// Commit replaces the file with the written content
+//
+// Returns:
+// - error: error if the file cannot be replaced, in which case it is left
+// unchanged
+func (w *AtomicWriter) Commit() error {
+	if w.done {
+		return fmt.Errorf("failed to write file: %w", os.ErrClosed)
+	}
+	w.done = true
+	defer os.Remove(w.temp.Name())
+
+	if err := w.commit(); err != nil {
+		w.temp.Close()
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return nil
+}
+
+// Close discards the written content unless Commit has been called
+//
+// Returns:
+// - error: error if the temporary file cannot be removed
+func (w *AtomicWriter) Close() error {
+	if w.done {
+		return nil
+	}
+	w.done = true
+
+	w.temp.Close()
+	if err := os.Remove(w.temp.Name()); err != nil {
+		return fmt.Errorf("failed to discard file: %w", err)
+	}
+
+	return nil
+}
+
+// commit Syncs the temporary file and renames it over the file
+func (w *AtomicWriter) commit() error {
+	previous, err := os.Stat(string(w.file))
+	if errors.Is(err, os.ErrNotExist) {
+		previous = nil
+	} else if err != nil {
+		return err
+	}
+
+	perm := w.options.Perm
+	if perm == 0 {
+		perm = 0o644
+		if previous != nil {
+			perm = previous.Mode().Perm()
+		}
+	}
+
+	if err := w.temp.Chmod(perm); err != nil {
+		return err
+	}
+
+	if err := chown(w.temp, previous, w.options.Owner); err != nil {
+		return err
+	}
+
+	if err := w.temp.Sync(); err != nil {
+		return err
+	}
+
+	if err := w.temp.Close(); err != nil {
+		return err
+	}
+
+	if w.options.Backup != "" && previous != nil {
+		if err := backup(w.file, File(string(w.file)+w.options.Backup)); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(w.temp.Name(), string(w.file)); err != nil {
+		return err
+	}
+
+	return syncDir(string(w.file.Dir()))
+}
+
+// backup Keeps a copy of a file, as a hard link when possible
+func backup(file, target File) error {
+	if err := target.Remove(); err != nil {
+		return err
+	}
+
+	if err := os.Link(string(file), string(target)); err == nil {
+		return nil
+	}
+
+	return file.CopyTo(target)
+}
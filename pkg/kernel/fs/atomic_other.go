@@ -0,0 +1,20 @@
+//go:build !unix
+
+package fs
+
+import "os"
+
+// chown Sets the owner of a file, the owner of the previous version being
+// kept by the system
+func chown(file *os.File, previous os.FileInfo, owner *Owner) error {
+	if owner != nil {
+		return file.Chown(owner.UID, owner.GID)
+	}
+
+	return nil
+}
+
+// syncDir Does nothing, directories cannot be synced on this system
+func syncDir(dir string) error {
+	return nil
+}
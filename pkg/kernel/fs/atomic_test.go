@@ -0,0 +1,74 @@
+package fs_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAtomicWriter(t *testing.T) {
+	dir := fs.Directory(t.TempDir())
+	file := dir.Join("state.json")
+	assert.NoError(t, file.WriteAtomic([]byte("{}\n"), 0o640))
+
+	writer, err := fs.NewAtomicWriter(file, fs.AtomicOptions{Backup: ".bak"})
+	assert.NoError(t, err)
+	defer writer.Close()
+
+	for i := range 3 {
+		_, err := fmt.Fprintf(writer, "%d\n", i)
+		assert.NoError(t, err)
+	}
+
+	content, err := file.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "{}\n", string(content))
+
+	assert.NoError(t, writer.Commit())
+	assert.ErrorIs(t, writer.Commit(), os.ErrClosed)
+	_, err = writer.Write([]byte("x"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+
+	content, err = file.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "0\n1\n2\n", string(content))
+
+	info, err := file.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	content, err = dir.Join("state.json.bak").ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "{}\n", string(content))
+
+	files, _, err := dir.List()
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+}
+
+func TestAtomicWriterDiscard(t *testing.T) {
+	dir := fs.Directory(t.TempDir())
+	file := dir.Join("state.json")
+
+	writer, err := fs.NewAtomicWriter(file, fs.AtomicOptions{})
+	assert.NoError(t, err)
+
+	_, err = writer.Write([]byte("partial"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.NoError(t, writer.Close())
+
+	exists, err := file.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	files, _, err := dir.List()
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	_, err = fs.NewAtomicWriter(dir.Join("missing", "state.json"), fs.AtomicOptions{})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
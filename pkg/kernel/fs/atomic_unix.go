@@ -0,0 +1,43 @@
+//go:build unix
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// chown Sets the owner of a file, keeping the owner of the previous version
+// when owner is nil and the process is allowed to
+func chown(file *os.File, previous os.FileInfo, owner *Owner) error {
+	if owner != nil {
+		return file.Chown(owner.UID, owner.GID)
+	}
+
+	if previous == nil {
+		return nil
+	}
+
+	stat, ok := previous.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	if err := file.Chown(int(stat.Uid), int(stat.Gid)); err != nil && !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+
+	return nil
+}
+
+// syncDir Flushes the entries of a directory to the disk
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return file.Sync()
+}
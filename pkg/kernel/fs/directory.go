@@ -1,3 +1,168 @@
 package fs
 
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Directory is the path of a directory.
 type Directory string
+
+// String returns the path of the directory.
+func (d Directory) String() string {
+	return string(d)
+}
+
+// Base returns the last element of the path.
+func (d Directory) Base() string {
+	return filepath.Base(string(d))
+}
+
+// Parent returns the directory holding the directory.
+func (d Directory) Parent() Directory {
+	return Directory(filepath.Dir(string(d)))
+}
+
+// Join returns a file of the directory, e.g. d.Join("conf", "app.yaml").
+func (d Directory) Join(elem ...string) File {
+	return File(filepath.Join(append([]string{string(d)}, elem...)...))
+}
+
+// Sub returns a subdirectory of the directory.
+func (d Directory) Sub(elem ...string) Directory {
+	return Directory(filepath.Join(append([]string{string(d)}, elem...)...))
+}
+
+// Exists reports whether the directory exists
+//
+// Returns:
+// - bool: true if the path exists and is a directory
+// - error: error if the path cannot be checked
+func (d Directory) Exists() (bool, error) {
+	info, err := os.Stat(string(d))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to stat directory: %w", err)
+	}
+
+	return info.IsDir(), nil
+}
+
+// Stat returns the description of the directory
+//
+// Returns:
+// - os.FileInfo: the directory information
+// - error: error if the directory cannot be described
+func (d Directory) Stat() (os.FileInfo, error) {
+	info, err := os.Stat(string(d))
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat directory: %w", err)
+	}
+
+	return info, nil
+}
+
+// List returns the entries of the directory, sorted by name
+//
+// Returns:
+// - []File: the files of the directory
+// - []Directory: the subdirectories of the directory
+// - error: error if the directory cannot be read
+func (d Directory) List() ([]File, []Directory, error) {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list directory: %w", err)
+	}
+
+	var files []File
+	var dirs []Directory
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, d.Sub(entry.Name()))
+		} else {
+			files = append(files, d.Join(entry.Name()))
+		}
+	}
+
+	return files, dirs, nil
+}
+
+// Walk calls fn for every file of the directory and its subdirectories, in
+// lexical order
+//
+// Parameters:
+// - fn: func(File) error - called for each file, an error stops the walk;
+// filepath.SkipAll stops it without error
+//
+// Returns:
+// - error: error if a directory cannot be read or fn fails
+func (d Directory) Walk(fn func(File) error) error {
+	return filepath.WalkDir(string(d), func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to walk directory: %w", err)
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		return fn(File(path))
+	})
+}
+
+// Glob returns the files of the directory matching a pattern
+//
+// Parameters:
+// - pattern: string - a filepath.Match pattern relative to the directory, e.g. "*.yaml"
+//
+// Returns:
+// - []File: the matching files, sorted
+// - error: error if the pattern is malformed
+func (d Directory) Glob(pattern string) ([]File, error) {
+	matches, err := filepath.Glob(filepath.Join(string(d), pattern))
+	if err != nil {
+		return nil, fmt.Errorf("failed to glob directory: %w", err)
+	}
+
+	files := make([]File, 0, len(matches))
+	for _, match := range matches {
+		if info, err := os.Stat(match); err == nil && !info.IsDir() {
+			files = append(files, File(match))
+		}
+	}
+
+	return files, nil
+}
+
+// MkdirAll creates the directory and its missing parents
+//
+// Parameters:
+// - perm: os.FileMode - the permissions of the created directories
+//
+// Returns:
+// - error: error if the directory cannot be created
+func (d Directory) MkdirAll(perm os.FileMode) error {
+	if err := os.MkdirAll(string(d), perm); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveAll removes the directory and its content, a missing directory is not
+// an error
+//
+// Returns:
+// - error: error if the directory cannot be removed
+func (d Directory) RemoveAll() error {
+	if err := os.RemoveAll(string(d)); err != nil {
+		return fmt.Errorf("failed to remove directory: %w", err)
+	}
+
+	return nil
+}
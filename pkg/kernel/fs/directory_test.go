@@ -0,0 +1,58 @@
+package fs_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectory(t *testing.T) {
+	root := fs.Directory(t.TempDir())
+	conf := root.Sub("etc", "conf")
+
+	exists, err := conf.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, conf.MkdirAll(0o755))
+	assert.NoError(t, conf.Join("b.yaml").WriteAtomic([]byte("b"), 0o644))
+	assert.NoError(t, conf.Join("a.json").WriteAtomic([]byte("a"), 0o644))
+	assert.NoError(t, conf.Sub("d").MkdirAll(0o755))
+	assert.NoError(t, conf.Sub("d").Join("c.yaml").WriteAtomic([]byte("c"), 0o644))
+
+	exists, err = conf.Exists()
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, root.Sub("etc"), conf.Parent())
+	assert.Equal(t, "conf", conf.Base())
+
+	files, dirs, err := conf.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []fs.File{conf.Join("a.json"), conf.Join("b.yaml")}, files)
+	assert.Equal(t, []fs.Directory{conf.Sub("d")}, dirs)
+
+	var walked []fs.File
+	assert.NoError(t, root.Walk(func(file fs.File) error {
+		walked = append(walked, file)
+		return nil
+	}))
+	assert.Equal(t, []fs.File{conf.Join("a.json"), conf.Join("b.yaml"), conf.Join("d", "c.yaml")}, walked)
+
+	matches, err := conf.Glob("*.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, []fs.File{conf.Join("b.yaml")}, matches)
+
+	_, err = conf.Glob("[")
+	assert.Error(t, err)
+
+	_, _, err = root.Sub("missing").List()
+	assert.ErrorContains(t, err, "failed to list directory")
+
+	assert.NoError(t, root.Sub("etc").RemoveAll())
+	exists, err = conf.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+	assert.Equal(t, filepath.Join(root.String(), "x"), root.Join("x").String())
+}
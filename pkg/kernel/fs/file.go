@@ -1,3 +1,181 @@
 package fs
 
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File is the path of a file.
 type File string
+
+// String returns the path of the file.
+func (f File) String() string {
+	return string(f)
+}
+
+// Base returns the last element of the path, e.g. "app.yaml".
+func (f File) Base() string {
+	return filepath.Base(string(f))
+}
+
+// Ext returns the extension of the file, including the dot, e.g. ".yaml".
+func (f File) Ext() string {
+	return filepath.Ext(string(f))
+}
+
+// Name returns the base of the file without its extension, e.g. "app".
+func (f File) Name() string {
+	return strings.TrimSuffix(f.Base(), f.Ext())
+}
+
+// Dir returns the directory holding the file.
+func (f File) Dir() Directory {
+	return Directory(filepath.Dir(string(f)))
+}
+
+// Exists reports whether the file exists
+//
+// Returns:
+// - bool: true if the path exists and is not a directory
+// - error: error if the path cannot be checked
+func (f File) Exists() (bool, error) {
+	info, err := os.Stat(string(f))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return !info.IsDir(), nil
+}
+
+// Stat returns the description of the file
+//
+// Returns:
+// - os.FileInfo: the file information
+// - error: error if the file cannot be described
+func (f File) Stat() (os.FileInfo, error) {
+	info, err := os.Stat(string(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	return info, nil
+}
+
+// Open opens the file for reading
+//
+// Returns:
+// - *os.File: the opened file, to be closed by the caller
+// - error: error if the file cannot be opened
+func (f File) Open() (*os.File, error) {
+	file, err := os.Open(string(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return file, nil
+}
+
+// Create creates or truncates the file for writing
+//
+// Returns:
+// - *os.File: the created file, to be closed by the caller
+// - error: error if the file cannot be created
+func (f File) Create() (*os.File, error) {
+	file, err := os.Create(string(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	return file, nil
+}
+
+// ReadAll reads the whole content of the file
+//
+// Returns:
+// - []byte: the content of the file
+// - error: error if the file cannot be read
+func (f File) ReadAll() ([]byte, error) {
+	content, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return content, nil
+}
+
+// WriteAtomic replaces the content of the file
+//
+// The content is written with an AtomicWriter, so that readers see either the
+// previous or the new content, never a partial one, even after a crash. The
+// owner of the previous version is kept.
+//
+// Parameters:
+// - data: []byte - the new content
+// - perm: os.FileMode - the permissions of the file
+//
+// Returns:
+// - error: error if the content cannot be written
+func (f File) WriteAtomic(data []byte, perm os.FileMode) error {
+	writer, err := NewAtomicWriter(f, AtomicOptions{Perm: perm})
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+
+	return writer.Commit()
+}
+
+// CopyTo copies the content of the file to another file
+//
+// Parameters:
+// - target: File - the destination, created or truncated
+//
+// Returns:
+// - error: error if the file cannot be copied
+func (f File) CopyTo(target File) error {
+	source, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	destination, err := target.Create()
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(destination, source); err != nil {
+		destination.Close()
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	if err := destination.Close(); err != nil {
+		return fmt.Errorf("failed to copy file: %w", err)
+	}
+
+	return nil
+}
+
+// Remove removes the file, a missing file is not an error
+//
+// Returns:
+// - error: error if the file cannot be removed
+func (f File) Remove() error {
+	if err := os.Remove(string(f)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove file: %w", err)
+	}
+
+	return nil
+}
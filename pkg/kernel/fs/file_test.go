@@ -0,0 +1,64 @@
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilePath(t *testing.T) {
+	file := fs.File(filepath.Join("conf", "app.yaml"))
+
+	assert.Equal(t, "app.yaml", file.Base())
+	assert.Equal(t, ".yaml", file.Ext())
+	assert.Equal(t, "app", file.Name())
+	assert.Equal(t, fs.Directory("conf"), file.Dir())
+	assert.Equal(t, filepath.Join("conf", "app.yaml"), file.String())
+}
+
+func TestFileReadWrite(t *testing.T) {
+	file := fs.Directory(t.TempDir()).Join("app.yaml")
+
+	exists, err := file.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = file.ReadAll()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	assert.ErrorContains(t, err, "failed to read file")
+
+	assert.NoError(t, file.WriteAtomic([]byte("a: 1\n"), 0o640))
+	assert.NoError(t, file.WriteAtomic([]byte("a: 2\n"), 0o640))
+
+	content, err := file.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 2\n", string(content))
+
+	info, err := file.Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	entries, err := os.ReadDir(file.Dir().String())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	copied := file.Dir().Join("copy.yaml")
+	assert.NoError(t, file.CopyTo(copied))
+	content, err = copied.ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 2\n", string(content))
+
+	assert.NoError(t, file.Remove())
+	assert.NoError(t, file.Remove())
+
+	exists, err = file.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = fs.File(file.Dir()).Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists, "a directory is not a file")
+}
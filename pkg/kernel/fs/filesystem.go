@@ -0,0 +1,171 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrReadOnly is returned by the write operations of read-only file systems.
+var ErrReadOnly = errors.New("read-only file system")
+
+// defaultPollInterval is the interval at which polling watchers check files.
+const defaultPollInterval = time.Second
+
+// FileSystem is the set of file operations used by the SDK, so that code can
+// run against the disk, memory or embedded files alike.
+//
+// Errors follow the os package conventions: a missing file gives an error
+// matching os.ErrNotExist with errors.Is.
+type FileSystem interface {
+	// Open opens a file for reading.
+	Open(name string) (io.ReadCloser, error)
+	// WriteFile creates or replaces a file.
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	// Stat describes a file or a directory.
+	Stat(name string) (os.FileInfo, error)
+	// ReadDir lists a directory, sorted by name.
+	ReadDir(name string) ([]os.DirEntry, error)
+	// MkdirAll creates a directory and its missing parents.
+	MkdirAll(name string, perm os.FileMode) error
+	// Rename moves a file, replacing the target.
+	Rename(oldname, newname string) error
+	// Remove removes a file or an empty directory.
+	Remove(name string) error
+	// Watch blocks until the context is done, calling changed each time the
+	// file is created, modified or removed.
+	Watch(ctx context.Context, name string, changed func()) error
+}
+
+// Open opens a file of a file system for reading
+//
+// Parameters:
+// - fsys: FileSystem - the file system, the OS when nil
+// - name: string - the path of the file
+//
+// Returns:
+// - io.ReadCloser: the content of the file, to be closed by the caller
+// - error: error if the file cannot be opened
+func Open(fsys FileSystem, name string) (io.ReadCloser, error) {
+	if fsys == nil {
+		fsys = OS{}
+	}
+
+	return fsys.Open(name)
+}
+
+// ReadFile reads the whole content of a file of a file system
+//
+// Parameters:
+// - fsys: FileSystem - the file system, the OS when nil
+// - name: string - the path of the file
+//
+// Returns:
+// - []byte: the content of the file
+// - error: error if the file cannot be read
+func ReadFile(fsys FileSystem, name string) ([]byte, error) {
+	file, err := Open(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// OS is the FileSystem of the operating system.
+type OS struct {
+	// PollInterval is the interval at which Watch checks the file when the
+	// notifications of the system are unavailable. Defaults to one second.
+	PollInterval time.Duration
+}
+
+// Open implements FileSystem.
+func (OS) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+// WriteFile implements FileSystem.
+func (OS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+// Stat implements FileSystem.
+func (OS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+// ReadDir implements FileSystem.
+func (OS) ReadDir(name string) ([]os.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+// MkdirAll implements FileSystem.
+func (OS) MkdirAll(name string, perm os.FileMode) error {
+	return os.MkdirAll(name, perm)
+}
+
+// Rename implements FileSystem.
+func (OS) Rename(oldname, newname string) error {
+	return os.Rename(oldname, newname)
+}
+
+// Remove implements FileSystem.
+func (OS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// Watch implements FileSystem with the Watch function, using the notifications
+// of the system when available.
+func (o OS) Watch(ctx context.Context, name string, changed func()) error {
+	return Watch(ctx, File(name), WatchOptions{PollInterval: o.PollInterval}, func(Event) { changed() })
+}
+
+// poll Calls changed each time the state returned by check changes
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the polling
+// - interval: time.Duration - the interval between two checks
+// - check: func() (string, error) - returns the state of the watched object
+// - changed: func() - called when the state changes
+//
+// Returns:
+// - error: the check error, or the context error once polling stops
+func poll(ctx context.Context, interval time.Duration, check func() (string, error), changed func()) error {
+	last, err := check()
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		state, err := check()
+		if err != nil {
+			return err
+		}
+
+		if state != last {
+			last = state
+			changed()
+		}
+	}
+}
+
+// slashPath Returns a path cleaned, slash separated and without leading slash,
+// empty for the root
+func slashPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
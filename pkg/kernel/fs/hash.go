@@ -0,0 +1,147 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// HashAlgorithm is the digest algorithm of File.Hash and Directory.Hash.
+type HashAlgorithm string
+
+const (
+	// SHA256 is the SHA-256 algorithm.
+	SHA256 HashAlgorithm = "sha256"
+	// SHA512 is the SHA-512 algorithm.
+	SHA512 HashAlgorithm = "sha512"
+)
+
+// new Returns a hash of the algorithm
+func (a HashAlgorithm) new() (hash.Hash, error) {
+	switch a {
+	case SHA256:
+		return sha256.New(), nil
+	case SHA512:
+		return sha512.New(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported hash algorithm: %s", a)
+}
+
+// Hash returns the digest of the content of the file
+//
+// Parameters:
+// - algo: HashAlgorithm - the digest algorithm
+//
+// Returns:
+// - string: the hexadecimal digest
+// - error: error if the file cannot be read
+func (f File) Hash(algo HashAlgorithm) (string, error) {
+	h, err := algo.new()
+	if err != nil {
+		return "", err
+	}
+
+	file, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("failed to hash file: %w", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Hash returns a digest of the tree of the directory
+//
+// The digest is built like a Merkle tree: each file is hashed, each directory
+// hashes the sorted names, types and digests of its entries. It only depends
+// on names, contents and symbolic link targets, so that the same tree gives the
+// same digest whatever its location, permissions or modification times.
+//
+// Parameters:
+// - algo: HashAlgorithm - the digest algorithm
+// - ignore: ...string - filepath.Match patterns of the base names of the
+// files and directories to skip, e.g. ".git"
+//
+// Returns:
+// - string: the hexadecimal digest
+// - error: error if the tree cannot be read
+func (d Directory) Hash(algo HashAlgorithm, ignore ...string) (string, error) {
+	if _, err := algo.new(); err != nil {
+		return "", err
+	}
+
+	digest, err := d.hash(algo, ignore)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash directory: %w", err)
+	}
+
+	return hex.EncodeToString(digest), nil
+}
+
+// hash Returns the digest of a directory
+func (d Directory) hash(algo HashAlgorithm, ignore []string) ([]byte, error) {
+	entries, err := os.ReadDir(string(d))
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(entries, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	tree, _ := algo.new()
+	for _, entry := range entries {
+		if matchAny(entry.Name(), ignore) {
+			continue
+		}
+
+		var kind string
+		var digest []byte
+
+		switch {
+		case entry.Type()&os.ModeSymlink != 0:
+			target, err := os.Readlink(string(d.Join(entry.Name())))
+			if err != nil {
+				return nil, err
+			}
+
+			h, _ := algo.new()
+			h.Write([]byte(target))
+			kind, digest = "link", h.Sum(nil)
+		case entry.IsDir():
+			if digest, err = d.Sub(entry.Name()).hash(algo, ignore); err != nil {
+				return nil, err
+			}
+			kind = "tree"
+		case entry.Type().IsRegular():
+			file, err := d.Join(entry.Name()).Open()
+			if err != nil {
+				return nil, err
+			}
+
+			h, _ := algo.new()
+			_, err = io.Copy(h, file)
+			file.Close()
+			if err != nil {
+				return nil, err
+			}
+			kind, digest = "blob", h.Sum(nil)
+		default:
+			continue
+		}
+
+		fmt.Fprintf(tree, "%s %s\x00", kind, entry.Name())
+		tree.Write(digest)
+	}
+
+	return tree.Sum(nil), nil
+}
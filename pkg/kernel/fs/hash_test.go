@@ -0,0 +1,56 @@
+package fs_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileHash(t *testing.T) {
+	file := fs.Directory(t.TempDir()).Join("app.yaml")
+	assert.NoError(t, os.WriteFile(string(file), []byte("abc"), 0o600))
+
+	digest, err := file.Hash(fs.SHA256)
+	assert.NoError(t, err)
+	assert.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", digest)
+
+	_, err = file.Hash("md4")
+	assert.Error(t, err)
+}
+
+func TestDirectoryHash(t *testing.T) {
+	tree := func() fs.Directory {
+		dir := fs.Directory(t.TempDir())
+		assert.NoError(t, dir.Sub("conf").MkdirAll(0o755))
+		assert.NoError(t, os.WriteFile(string(dir.Join("conf", "app.yaml")), []byte("a: 1\n"), 0o600))
+		assert.NoError(t, os.WriteFile(string(dir.Join("README")), []byte("readme"), 0o644))
+		return dir
+	}
+
+	first, second := tree(), tree()
+	assert.NoError(t, os.Chmod(string(second.Join("README")), 0o600))
+
+	digest, err := first.Hash(fs.SHA256)
+	assert.NoError(t, err)
+	assert.Len(t, digest, 64)
+
+	same, err := second.Hash(fs.SHA256)
+	assert.NoError(t, err)
+	assert.Equal(t, digest, same)
+
+	assert.NoError(t, os.WriteFile(string(second.Join(".cache")), []byte("x"), 0o600))
+	ignored, err := second.Hash(fs.SHA256, ".cache")
+	assert.NoError(t, err)
+	assert.Equal(t, digest, ignored)
+
+	assert.NoError(t, os.Rename(string(second.Join("conf", "app.yaml")), string(second.Join("conf", "db.yaml"))))
+	renamed, err := second.Hash(fs.SHA256, ".cache")
+	assert.NoError(t, err)
+	assert.NotEqual(t, digest, renamed)
+
+	long, err := first.Hash(fs.SHA512)
+	assert.NoError(t, err)
+	assert.Len(t, long, 128)
+}
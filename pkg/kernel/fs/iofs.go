@@ -0,0 +1,91 @@
+package fs
+
+import (
+	"context"
+	"io"
+	iofs "io/fs"
+	"os"
+)
+
+// ReadOnly is a FileSystem reading an io/fs.FS, such as an embed.FS.
+//
+// Paths are resolved relative to the root of the FS, a leading slash being
+// ignored. Write operations fail with ErrReadOnly and Watch never reports a
+// change.
+type ReadOnly struct {
+	FS iofs.FS
+}
+
+// FromFS adapts an io/fs.FS into a read-only FileSystem
+//
+// Parameters:
+// - fsys: io/fs.FS - the file system to read, e.g. an embed.FS
+//
+// Returns:
+// - *ReadOnly: the adapted file system
+func FromFS(fsys iofs.FS) *ReadOnly {
+	return &ReadOnly{FS: fsys}
+}
+
+// Open implements FileSystem.
+func (r *ReadOnly) Open(name string) (io.ReadCloser, error) {
+	file, err := r.FS.Open(r.path(name))
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		file.Close()
+		return nil, &os.PathError{Op: "open", Path: name, Err: errIsDirectory}
+	}
+
+	return file, nil
+}
+
+// WriteFile implements FileSystem.
+func (r *ReadOnly) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return &os.PathError{Op: "write", Path: name, Err: ErrReadOnly}
+}
+
+// Stat implements FileSystem.
+func (r *ReadOnly) Stat(name string) (os.FileInfo, error) {
+	return iofs.Stat(r.FS, r.path(name))
+}
+
+// ReadDir implements FileSystem.
+func (r *ReadOnly) ReadDir(name string) ([]os.DirEntry, error) {
+	return iofs.ReadDir(r.FS, r.path(name))
+}
+
+// MkdirAll implements FileSystem.
+func (r *ReadOnly) MkdirAll(name string, perm os.FileMode) error {
+	return &os.PathError{Op: "mkdir", Path: name, Err: ErrReadOnly}
+}
+
+// Rename implements FileSystem.
+func (r *ReadOnly) Rename(oldname, newname string) error {
+	return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: ErrReadOnly}
+}
+
+// Remove implements FileSystem.
+func (r *ReadOnly) Remove(name string) error {
+	return &os.PathError{Op: "remove", Path: name, Err: ErrReadOnly}
+}
+
+// Watch implements FileSystem. The content never changes, so it only waits
+// for the context to be done.
+func (r *ReadOnly) Watch(ctx context.Context, name string, changed func()) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// path Converts a path into a valid io/fs path
+func (r *ReadOnly) path(name string) string {
+	cleaned := slashPath(name)
+	if cleaned == "" {
+		return "."
+	}
+
+	return cleaned
+}
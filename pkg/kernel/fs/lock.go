@@ -0,0 +1,242 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned when a lock is held by another process.
+var ErrLocked = errors.New("file is locked")
+
+// maxLockInterval is the longest wait between two attempts of Lock and RLock.
+const maxLockInterval = 100 * time.Millisecond
+
+// lockDirName is the name of the lock file created by Directory.LockDir.
+const lockDirName = ".lock"
+
+// Lock is an advisory lock on a file, held until Unlock or Close is called or
+// the process exits.
+//
+// Locks use flock on Unix systems: they are only respected by processes using
+// locks too, and are not supported on other systems.
+type Lock struct {
+	file *os.File
+}
+
+// Lock acquires an exclusive lock on the file, creating it when missing
+//
+// Parameters:
+// - ctx: context.Context - bounds the wait for a lock held by another process
+//
+// Returns:
+// - *Lock: the lock, to be released by the caller
+// - error: error if the lock cannot be acquired before the context is done
+func (f File) Lock(ctx context.Context) (*Lock, error) {
+	return f.lock(ctx, false)
+}
+
+// RLock acquires a shared lock on the file, creating it when missing
+//
+// Shared locks can be held by several processes at once, but not while an
+// exclusive lock is held.
+//
+// Parameters:
+// - ctx: context.Context - bounds the wait for an exclusive lock held by
+// another process
+//
+// Returns:
+// - *Lock: the lock, to be released by the caller
+// - error: error if the lock cannot be acquired before the context is done
+func (f File) RLock(ctx context.Context) (*Lock, error) {
+	return f.lock(ctx, true)
+}
+
+// TryLock acquires an exclusive lock on the file without waiting
+//
+// Returns:
+// - *Lock: the lock, to be released by the caller
+// - error: error matching ErrLocked if the lock is held by another process
+func (f File) TryLock() (*Lock, error) {
+	file, err := os.OpenFile(string(f), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock file: %w", err)
+	}
+
+	if err := flock(file, false); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock file: %w", err)
+	}
+
+	return &Lock{file: file}, nil
+}
+
+// lock Acquires a lock, retrying with an increasing interval until the
+// context is done
+func (f File) lock(ctx context.Context, shared bool) (*Lock, error) {
+	file, err := os.OpenFile(string(f), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock file: %w", err)
+	}
+
+	interval := time.Millisecond
+	for {
+		err := flock(file, shared)
+		if err == nil {
+			return &Lock{file: file}, nil
+		}
+
+		if !errors.Is(err, ErrLocked) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock file: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			file.Close()
+			return nil, fmt.Errorf("failed to lock file: %w", errors.Join(ErrLocked, ctx.Err()))
+		case <-time.After(interval):
+		}
+
+		interval = min(interval*2, maxLockInterval)
+	}
+}
+
+// File returns the locked file.
+func (l *Lock) File() File {
+	return File(l.file.Name())
+}
+
+// Unlock releases the lock, calling it again does nothing
+//
+// Returns:
+// - error: error if the lock file cannot be closed
+func (l *Lock) Unlock() error {
+	if l.file == nil {
+		return nil
+	}
+
+	file := l.file
+	l.file = nil
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to unlock file: %w", err)
+	}
+
+	return nil
+}
+
+// Close releases the lock, as Unlock.
+func (l *Lock) Close() error {
+	return l.Unlock()
+}
+
+// LockDir acquires an exclusive lock on the directory
+//
+// The lock is taken on a ".lock" file of the directory, so it only excludes
+// the processes locking the directory with LockDir.
+//
+// Parameters:
+// - ctx: context.Context - bounds the wait for a lock held by another process
+//
+// Returns:
+// - *Lock: the lock, to be released by the caller
+// - error: error if the lock cannot be acquired before the context is done
+func (d Directory) LockDir(ctx context.Context) (*Lock, error) {
+	return d.Join(lockDirName).Lock(ctx)
+}
+
+// PIDFile is a locked file holding the identifier of the running process,
+// guarding against several instances of a program running at once.
+type PIDFile struct {
+	lock *Lock
+}
+
+// NewPIDFile writes the identifier of the process to a locked file
+//
+// The file is locked as long as the process runs, and only the lock tells
+// whether another instance is running: a file left behind by a process that
+// died is reused, even when its identifier now belongs to another process.
+//
+// Parameters:
+// - file: File - the PID file, e.g. "/run/app.pid"
+//
+// Returns:
+// - *PIDFile: the PID file, to be closed when the process stops
+// - error: error matching ErrLocked if another instance is running
+func NewPIDFile(file File) (*PIDFile, error) {
+	lock, err := file.TryLock()
+	if errors.Is(err, ErrLocked) {
+		pid, _, _ := ReadPID(file)
+		return nil, fmt.Errorf("failed to create PID file: process %d is running: %w", pid, ErrLocked)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create PID file: %w", err)
+	}
+
+	if err := writePID(lock.file); err != nil {
+		lock.Unlock()
+		return nil, fmt.Errorf("failed to create PID file: %w", err)
+	}
+
+	return &PIDFile{lock: lock}, nil
+}
+
+// Close empties the PID file and releases its lock
+//
+// The file is not removed: another instance could lock it between its removal
+// and the release of the lock while a third one creates a new file, and both
+// would run at once.
+//
+// Returns:
+// - error: error if the file cannot be emptied or unlocked
+func (p *PIDFile) Close() error {
+	if p.lock.file == nil {
+		return nil
+	}
+
+	err := p.lock.file.Truncate(0)
+
+	return errors.Join(err, p.lock.Unlock())
+}
+
+// ReadPID reads the identifier of the process of a PID file
+//
+// Parameters:
+// - file: File - the PID file
+//
+// Returns:
+// - int: the process identifier
+// - bool: true if the process is running
+// - error: error if the file cannot be read or holds no identifier
+func ReadPID(file File) (int, bool, error) {
+	content, err := file.ReadAll()
+	if err != nil {
+		return 0, false, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil || pid <= 0 {
+		return 0, false, fmt.Errorf("failed to read PID file: invalid identifier %q", strings.TrimSpace(string(content)))
+	}
+
+	return pid, processAlive(pid), nil
+}
+
+// writePID Replaces the content of a file with the identifier of the process
+func writePID(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+
+	if _, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
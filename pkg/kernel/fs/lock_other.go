@@ -0,0 +1,24 @@
+//go:build !unix
+
+package fs
+
+import (
+	"errors"
+	"os"
+)
+
+// flock Returns an error, locks are not supported on this system
+func flock(file *os.File, shared bool) error {
+	return errors.ErrUnsupported
+}
+
+// processAlive Reports whether a process is running
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	process.Release()
+
+	return true
+}
@@ -0,0 +1,112 @@
+//go:build unix
+
+package fs_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLock(t *testing.T) {
+	file := fs.Directory(t.TempDir()).Join("data.lock")
+
+	lock, err := file.TryLock()
+	assert.NoError(t, err)
+	assert.Equal(t, file, lock.File())
+
+	_, err = file.TryLock()
+	assert.ErrorIs(t, err, fs.ErrLocked)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = file.RLock(ctx)
+	assert.ErrorIs(t, err, fs.ErrLocked)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	go func(held *fs.Lock) {
+		time.Sleep(20 * time.Millisecond)
+		held.Unlock()
+	}(lock)
+
+	lock, err = file.Lock(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, lock.Close())
+	assert.NoError(t, lock.Close())
+
+	first, err := file.RLock(context.Background())
+	assert.NoError(t, err)
+	defer first.Close()
+
+	second, err := file.RLock(context.Background())
+	assert.NoError(t, err)
+	defer second.Close()
+
+	_, err = file.TryLock()
+	assert.ErrorIs(t, err, fs.ErrLocked)
+}
+
+func TestDirectoryLockDir(t *testing.T) {
+	dir := fs.Directory(t.TempDir())
+
+	lock, err := dir.LockDir(context.Background())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = dir.LockDir(ctx)
+	assert.ErrorIs(t, err, fs.ErrLocked)
+
+	assert.NoError(t, lock.Unlock())
+}
+
+func TestPIDFile(t *testing.T) {
+	file := fs.Directory(t.TempDir()).Join("app.pid")
+	assert.NoError(t, os.WriteFile(string(file), []byte("99999999\n"), 0o644))
+
+	_, running, err := fs.ReadPID(file)
+	assert.NoError(t, err)
+	assert.False(t, running)
+
+	pidFile, err := fs.NewPIDFile(file)
+	assert.NoError(t, err)
+
+	pid, running, err := fs.ReadPID(file)
+	assert.NoError(t, err)
+	assert.True(t, running)
+	assert.Equal(t, os.Getpid(), pid)
+
+	_, err = fs.NewPIDFile(file)
+	assert.ErrorIs(t, err, fs.ErrLocked)
+	assert.ErrorContains(t, err, strconv.Itoa(os.Getpid()))
+
+	assert.NoError(t, pidFile.Close())
+	assert.NoError(t, pidFile.Close())
+
+	// The file is kept, emptied, and reused by the next instance.
+	_, _, err = fs.ReadPID(file)
+	assert.ErrorContains(t, err, "invalid identifier")
+
+	pidFile, err = fs.NewPIDFile(file)
+	assert.NoError(t, err)
+	assert.NoError(t, pidFile.Close())
+}
+
+func TestPIDFileReusedIdentifier(t *testing.T) {
+	// The stale file names a running process that does not hold the lock.
+	file := fs.Directory(t.TempDir()).Join("app.pid")
+	assert.NoError(t, os.WriteFile(string(file), []byte(strconv.Itoa(os.Getppid())+"\n"), 0o644))
+
+	pidFile, err := fs.NewPIDFile(file)
+	assert.NoError(t, err)
+
+	pid, _, err := fs.ReadPID(file)
+	assert.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+	assert.NoError(t, pidFile.Close())
+}
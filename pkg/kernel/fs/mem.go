@@ -0,0 +1,339 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	iofs "io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	errIsDirectory  = errors.New("is a directory")
+	errNotDirectory = errors.New("not a directory")
+	errNotEmpty     = errors.New("directory not empty")
+	errInvalid      = errors.New("invalid argument")
+)
+
+// Mem is a thread-safe in-memory FileSystem.
+//
+// Relative and absolute paths designate the same files, "a/b" and "/a/b" being
+// equivalent. Parent directories are created implicitly by WriteFile and
+// Rename. The zero value is an empty file system ready to use.
+type Mem struct {
+	mu       sync.RWMutex
+	nodes    map[string]*memNode
+	watchers map[string][]chan struct{}
+}
+
+// memNode is a file or a directory of a Mem file system. The data of a node is
+// never modified once stored, writes replace the node.
+type memNode struct {
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+	dir     bool
+}
+
+// NewMem creates an in-memory file system holding the given files
+//
+// Parameters:
+// - files: map[string]string - the content of the files, by path
+//
+// Returns:
+// - *Mem: the file system
+func NewMem(files map[string]string) *Mem {
+	m := &Mem{}
+	for name, content := range files {
+		_ = m.WriteFile(name, []byte(content), 0o644)
+	}
+
+	return m
+}
+
+// Open implements FileSystem.
+func (m *Mem) Open(name string) (io.ReadCloser, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if node.dir {
+		return nil, &os.PathError{Op: "open", Path: name, Err: errIsDirectory}
+	}
+
+	return io.NopCloser(bytes.NewReader(node.data)), nil
+}
+
+// WriteFile implements FileSystem.
+func (m *Mem) WriteFile(name string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := slashPath(name)
+	if node, ok := m.nodes[key]; ok && node.dir || key == "" {
+		return &os.PathError{Op: "write", Path: name, Err: errIsDirectory}
+	}
+
+	if err := m.mkdirAll("write", name, path.Dir(key), 0o755); err != nil {
+		return err
+	}
+
+	m.nodes[key] = &memNode{data: bytes.Clone(data), mode: perm.Perm(), modTime: time.Now()}
+	m.notify(key)
+
+	return nil
+}
+
+// Stat implements FileSystem.
+func (m *Mem) Stat(name string) (os.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &memInfo{name: path.Base("/" + slashPath(name)), node: node}, nil
+}
+
+// ReadDir implements FileSystem.
+func (m *Mem) ReadDir(name string) ([]os.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	node, err := m.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !node.dir {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: errNotDirectory}
+	}
+
+	key := slashPath(name)
+
+	var entries []os.DirEntry
+	for child, node := range m.nodes {
+		if child != "" && memParent(child) == key {
+			entries = append(entries, iofs.FileInfoToDirEntry(&memInfo{name: path.Base(child), node: node}))
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	return entries, nil
+}
+
+// MkdirAll implements FileSystem.
+func (m *Mem) MkdirAll(name string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.mkdirAll("mkdir", name, slashPath(name), perm)
+}
+
+// Rename implements FileSystem.
+func (m *Mem) Rename(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, err := m.lookup("rename", oldname)
+	if err != nil {
+		return err
+	}
+
+	oldKey, newKey := slashPath(oldname), slashPath(newname)
+	if oldKey == newKey {
+		return nil
+	}
+
+	// Like rename(2), a directory cannot be moved into itself and only replaces
+	// an empty directory.
+	if node.dir && strings.HasPrefix(newKey, oldKey+"/") {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errInvalid}
+	}
+
+	if target, ok := m.nodes[newKey]; ok {
+		switch {
+		case target.dir && !node.dir:
+			return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errIsDirectory}
+		case !target.dir && node.dir:
+			return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errNotDirectory}
+		case target.dir:
+			for child := range m.nodes {
+				if child != "" && memParent(child) == newKey {
+					return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errNotEmpty}
+				}
+			}
+		}
+	}
+
+	if err := m.mkdirAll("rename", newname, path.Dir(newKey), 0o755); err != nil {
+		return err
+	}
+
+	moved := map[string]*memNode{}
+	for key, child := range m.nodes {
+		if key == oldKey || strings.HasPrefix(key, oldKey+"/") {
+			moved[key] = child
+		}
+	}
+
+	for key := range moved {
+		delete(m.nodes, key)
+		m.notify(key)
+	}
+
+	for key, child := range moved {
+		m.nodes[newKey+key[len(oldKey):]] = child
+		m.notify(newKey + key[len(oldKey):])
+	}
+
+	return nil
+}
+
+// Remove implements FileSystem.
+func (m *Mem) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	node, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+
+	key := slashPath(name)
+	if node.dir {
+		for child := range m.nodes {
+			if child != "" && memParent(child) == key {
+				return &os.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+			}
+		}
+	}
+
+	delete(m.nodes, key)
+	m.notify(key)
+
+	return nil
+}
+
+// Watch implements FileSystem. Changes are notified as soon as they are made.
+func (m *Mem) Watch(ctx context.Context, name string, changed func()) error {
+	key := slashPath(name)
+	events := make(chan struct{}, 1)
+
+	m.mu.Lock()
+	if m.watchers == nil {
+		m.watchers = map[string][]chan struct{}{}
+	}
+	m.watchers[key] = append(m.watchers[key], events)
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		m.watchers[key] = slices.DeleteFunc(m.watchers[key], func(c chan struct{}) bool { return c == events })
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-events:
+			changed()
+		}
+	}
+}
+
+// lookup Returns the node of a path, m.mu must be held
+func (m *Mem) lookup(op, name string) (*memNode, error) {
+	key := slashPath(name)
+	if key == "" {
+		return &memNode{dir: true, mode: 0o755}, nil
+	}
+
+	node, ok := m.nodes[key]
+	if !ok {
+		return nil, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+
+	return node, nil
+}
+
+// mkdirAll Creates a directory and its parents, m.mu must be held for writing
+func (m *Mem) mkdirAll(op, name, key string, perm os.FileMode) error {
+	if m.nodes == nil {
+		m.nodes = map[string]*memNode{}
+	}
+
+	if key == "" || key == "." {
+		return nil
+	}
+
+	if node, ok := m.nodes[key]; ok {
+		if !node.dir {
+			return &os.PathError{Op: op, Path: name, Err: errNotDirectory}
+		}
+		return nil
+	}
+
+	if err := m.mkdirAll(op, name, memParent(key), perm); err != nil {
+		return err
+	}
+
+	m.nodes[key] = &memNode{dir: true, mode: perm.Perm(), modTime: time.Now()}
+	m.notify(key)
+
+	return nil
+}
+
+// notify Signals the watchers of a path, m.mu must be held
+func (m *Mem) notify(key string) {
+	for _, events := range m.watchers[key] {
+		select {
+		case events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// memParent Returns the key of the parent of a key
+func memParent(key string) string {
+	if parent := path.Dir(key); parent != "." {
+		return parent
+	}
+
+	return ""
+}
+
+// memInfo describes a node of a Mem file system.
+type memInfo struct {
+	name string
+	node *memNode
+}
+
+func (i *memInfo) Name() string       { return i.name }
+func (i *memInfo) Size() int64        { return int64(len(i.node.data)) }
+func (i *memInfo) ModTime() time.Time { return i.node.modTime }
+func (i *memInfo) IsDir() bool        { return i.node.dir }
+func (i *memInfo) Sys() any           { return nil }
+
+func (i *memInfo) Mode() os.FileMode {
+	if i.node.dir {
+		return os.ModeDir | i.node.mode
+	}
+
+	return i.node.mode
+}
@@ -0,0 +1,99 @@
+package fs_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMem(t *testing.T) {
+	mem := fs.NewMem(map[string]string{"/conf/app.yaml": "a: 1\n"})
+
+	content, err := fs.ReadFile(mem, "conf/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(content))
+
+	info, err := mem.Stat("/conf")
+	assert.NoError(t, err)
+	assert.True(t, info.IsDir())
+
+	assert.NoError(t, mem.WriteFile("/conf/db/db.yaml", []byte("b: 2\n"), 0o600))
+
+	entries, err := mem.ReadDir("/conf")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "app.yaml", entries[0].Name())
+	assert.Equal(t, "db", entries[1].Name())
+	assert.True(t, entries[1].IsDir())
+
+	assert.NoError(t, mem.Rename("/conf/db", "/data/db"))
+	content, err = fs.ReadFile(mem, "/data/db/db.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "b: 2\n", string(content))
+
+	_, err = mem.Stat("/conf/db/db.yaml")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	assert.Error(t, mem.Remove("/data/db"))
+	assert.NoError(t, mem.Remove("/data/db/db.yaml"))
+	assert.NoError(t, mem.Remove("/data/db"))
+
+	_, err = mem.Open("/conf")
+	assert.Error(t, err)
+	assert.Error(t, mem.WriteFile("/conf/app.yaml/x", nil, 0o600))
+}
+
+func TestMemRenameDirectory(t *testing.T) {
+	mem := fs.NewMem(map[string]string{
+		"/conf/db/db.yaml":  "a: 1\n",
+		"/data/app.yaml":    "b: 2\n",
+		"/backup/.keep":     "",
+		"/conf/app.yaml":    "c: 3\n",
+		"/archive/old.yaml": "d: 4\n",
+	})
+	assert.NoError(t, mem.MkdirAll("/empty", 0o755))
+
+	assert.ErrorContains(t, mem.Rename("/conf", "/conf/db/conf"), "invalid argument")
+	assert.ErrorContains(t, mem.Rename("/conf", "/conf/sub"), "invalid argument")
+
+	// A non-empty directory is never merged with the moved one.
+	assert.ErrorContains(t, mem.Rename("/conf", "/data"), "directory not empty")
+	_, err := mem.Stat("/data/db")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	assert.ErrorContains(t, mem.Rename("/conf/app.yaml", "/archive"), "is a directory")
+	assert.ErrorContains(t, mem.Rename("/archive", "/conf/app.yaml"), "not a directory")
+
+	assert.NoError(t, mem.Rename("/conf", "/empty"))
+	content, err := fs.ReadFile(mem, "/empty/db/db.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(content))
+}
+
+func TestMemWatch(t *testing.T) {
+	mem := &fs.Mem{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() {
+		done <- mem.Watch(ctx, "/app.yaml", func() { changed <- struct{}{} })
+	}()
+
+	assert.Eventually(t, func() bool {
+		_ = mem.WriteFile("/app.yaml", []byte("a: 1\n"), 0o600)
+		select {
+		case <-changed:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,238 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Overlay is a copy-on-write FileSystem layering a writable file system over
+// a read-only one.
+//
+// Reads look in Upper first, then in Lower. Writes only ever reach Upper, and
+// files removed or renamed away from Lower are hidden by in-memory whiteouts,
+// so Lower is never modified. Directories of Lower cannot be renamed.
+type Overlay struct {
+	Lower FileSystem
+	Upper FileSystem
+
+	mu        sync.RWMutex
+	whiteouts map[string]bool
+}
+
+// NewOverlay layers a writable file system over a read-only one
+//
+// Parameters:
+// - lower: FileSystem - the file system read when Upper has no file
+// - upper: FileSystem - the file system receiving every write, e.g. a *Mem
+//
+// Returns:
+// - *Overlay: the layered file system
+func NewOverlay(lower, upper FileSystem) *Overlay {
+	return &Overlay{Lower: lower, Upper: upper}
+}
+
+// Open implements FileSystem.
+func (o *Overlay) Open(name string) (io.ReadCloser, error) {
+	if o.hidden(name) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	file, err := o.Upper.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return o.Lower.Open(name)
+	}
+
+	return file, err
+}
+
+// WriteFile implements FileSystem.
+func (o *Overlay) WriteFile(name string, data []byte, perm os.FileMode) error {
+	if err := o.Upper.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	if err := o.Upper.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+
+	o.reveal(name)
+
+	return nil
+}
+
+// Stat implements FileSystem.
+func (o *Overlay) Stat(name string) (os.FileInfo, error) {
+	if o.hidden(name) {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+
+	info, err := o.Upper.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return o.Lower.Stat(name)
+	}
+
+	return info, err
+}
+
+// ReadDir implements FileSystem. The entries of both layers are merged, the
+// ones of Upper taking precedence.
+func (o *Overlay) ReadDir(name string) ([]os.DirEntry, error) {
+	if o.hidden(name) {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: os.ErrNotExist}
+	}
+
+	upper, upperErr := o.Upper.ReadDir(name)
+	if upperErr != nil && !errors.Is(upperErr, os.ErrNotExist) {
+		return nil, upperErr
+	}
+
+	lower, lowerErr := o.Lower.ReadDir(name)
+	if lowerErr != nil && !errors.Is(lowerErr, os.ErrNotExist) {
+		return nil, lowerErr
+	}
+
+	if upperErr != nil && lowerErr != nil {
+		return nil, upperErr
+	}
+
+	entries := slices.Clone(upper)
+	for _, entry := range lower {
+		if o.hidden(path.Join(slashPath(name), entry.Name())) {
+			continue
+		}
+		if slices.ContainsFunc(upper, func(e os.DirEntry) bool { return e.Name() == entry.Name() }) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	slices.SortFunc(entries, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	return entries, nil
+}
+
+// MkdirAll implements FileSystem.
+func (o *Overlay) MkdirAll(name string, perm os.FileMode) error {
+	if err := o.Upper.MkdirAll(name, perm); err != nil {
+		return err
+	}
+
+	o.reveal(name)
+
+	return nil
+}
+
+// Rename implements FileSystem. A file of Lower is copied to Upper under the
+// new name and hidden under the old one. A directory of Lower cannot be
+// renamed, even when Upper holds a copy of it, and ErrReadOnly is returned.
+func (o *Overlay) Rename(oldname, newname string) error {
+	info, err := o.Stat(oldname)
+	if err != nil {
+		return err
+	}
+
+	if lower, err := o.Lower.Stat(oldname); err == nil && lower.IsDir() {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: ErrReadOnly}
+	}
+
+	if _, err := o.Upper.Stat(oldname); err == nil {
+		if err := o.Upper.MkdirAll(filepath.Dir(newname), 0o755); err != nil {
+			return err
+		}
+		if err := o.Upper.Rename(oldname, newname); err != nil {
+			return err
+		}
+	} else {
+		content, err := ReadFile(o.Lower, oldname)
+		if err != nil {
+			return err
+		}
+
+		if err := o.WriteFile(newname, content, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	o.reveal(newname)
+	if _, err := o.Lower.Stat(oldname); err == nil {
+		o.hide(oldname)
+	}
+
+	return nil
+}
+
+// Remove implements FileSystem.
+func (o *Overlay) Remove(name string) error {
+	if _, err := o.Stat(name); err != nil {
+		return err
+	}
+
+	if entries, err := o.ReadDir(name); err == nil && len(entries) > 0 {
+		return &os.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+
+	if _, err := o.Upper.Stat(name); err == nil {
+		if err := o.Upper.Remove(name); err != nil {
+			return err
+		}
+	}
+
+	if _, err := o.Lower.Stat(name); err == nil {
+		o.hide(name)
+	}
+
+	return nil
+}
+
+// Watch implements FileSystem by watching both layers.
+func (o *Overlay) Watch(ctx context.Context, name string, changed func()) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, 2)
+	for _, layer := range []FileSystem{o.Upper, o.Lower} {
+		go func() { errs <- layer.Watch(ctx, name, changed) }()
+	}
+
+	err := <-errs
+	cancel()
+	<-errs
+
+	return err
+}
+
+// hidden Reports whether a path of Lower has been removed
+func (o *Overlay) hidden(name string) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.whiteouts[slashPath(name)]
+}
+
+// hide Hides a path of Lower
+func (o *Overlay) hide(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.whiteouts == nil {
+		o.whiteouts = map[string]bool{}
+	}
+	o.whiteouts[slashPath(name)] = true
+}
+
+// reveal Removes the whiteouts of a path and of its parents
+func (o *Overlay) reveal(name string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for key := slashPath(name); key != "" && key != "."; key = path.Dir(key) {
+		delete(o.whiteouts, key)
+	}
+}
@@ -0,0 +1,102 @@
+package fs_test
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnly(t *testing.T) {
+	fsys := fs.FromFS(fstest.MapFS{
+		"conf/app.yaml": {Data: []byte("a: 1\n")},
+	})
+
+	content, err := fs.ReadFile(fsys, "/conf/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(content))
+
+	entries, err := fsys.ReadDir("/conf")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	_, err = fsys.Open("/conf")
+	assert.Error(t, err)
+	assert.ErrorIs(t, fsys.WriteFile("/conf/app.yaml", nil, 0o600), fs.ErrReadOnly)
+	assert.ErrorIs(t, fsys.Remove("/conf/app.yaml"), fs.ErrReadOnly)
+}
+
+func TestOverlay(t *testing.T) {
+	lower := fs.FromFS(fstest.MapFS{
+		"conf/app.yaml":  {Data: []byte("a: 1\n")},
+		"conf/base.yaml": {Data: []byte("b: 1\n")},
+	})
+	overlay := fs.NewOverlay(lower, &fs.Mem{})
+
+	assert.NoError(t, overlay.WriteFile("/conf/app.yaml", []byte("a: 2\n"), 0o600))
+	assert.NoError(t, overlay.WriteFile("/conf/local.yaml", []byte("c: 1\n"), 0o600))
+
+	content, err := fs.ReadFile(overlay, "/conf/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 2\n", string(content))
+
+	content, err = fs.ReadFile(lower, "/conf/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(content))
+
+	entries, err := overlay.ReadDir("/conf")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app.yaml", "base.yaml", "local.yaml"}, names(entries))
+
+	assert.NoError(t, overlay.Rename("/conf/base.yaml", "/conf/defaults.yaml"))
+	assert.NoError(t, overlay.Remove("/conf/app.yaml"))
+
+	_, err = overlay.Stat("/conf/app.yaml")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	entries, err = overlay.ReadDir("/conf")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"defaults.yaml", "local.yaml"}, names(entries))
+
+	assert.NoError(t, overlay.WriteFile("/conf/app.yaml", []byte("a: 3\n"), 0o600))
+	content, err = fs.ReadFile(overlay, "/conf/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 3\n", string(content))
+}
+
+func TestOverlayRenameDirectory(t *testing.T) {
+	lower := fs.FromFS(fstest.MapFS{
+		"conf/app.yaml": {Data: []byte("a: 1\n")},
+	})
+	overlay := fs.NewOverlay(lower, &fs.Mem{})
+
+	// Upper holds a copy of /conf once a file is written in it.
+	assert.NoError(t, overlay.WriteFile("/conf/local.yaml", []byte("c: 1\n"), 0o600))
+	assert.ErrorIs(t, overlay.Rename("/conf", "/etc"), fs.ErrReadOnly)
+
+	entries, err := overlay.ReadDir("/conf")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app.yaml", "local.yaml"}, names(entries))
+
+	_, err = overlay.Stat("/etc")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// A directory only in Upper is renamed.
+	assert.NoError(t, overlay.WriteFile("/data/cache.json", []byte("{}"), 0o600))
+	assert.NoError(t, overlay.Rename("/data", "/cache"))
+
+	content, err := fs.ReadFile(overlay, "/cache/cache.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", string(content))
+}
+
+func names(entries []os.DirEntry) []string {
+	var result []string
+	for _, entry := range entries {
+		result = append(result, entry.Name())
+	}
+
+	return result
+}
@@ -0,0 +1,255 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrQuotaExceeded is returned when a write would exceed the budget of a
+// Quota.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota is a FileSystem confined to a directory, as Root, that rejects the
+// writes exceeding a budget of bytes or inodes.
+//
+// The consumption is computed when the quota is created and then tracked on
+// each change made through the quota. Changes made to the directory by other
+// means are only accounted for after Refresh.
+type Quota struct {
+	// MaxBytes is the budget of the total size of the files. Zero means
+	// unlimited.
+	MaxBytes int64
+	// MaxInodes is the budget of the number of files and directories. Zero
+	// means unlimited.
+	MaxInodes int64
+
+	root  *Root
+	mu    sync.Mutex
+	usage Usage
+}
+
+// NewQuota creates a file system enforcing a budget on a directory
+//
+// Parameters:
+// - dir: Directory - the root of the file system
+// - maxBytes: int64 - the budget of the total size of the files, zero for
+// unlimited
+// - maxInodes: int64 - the budget of the number of files and directories, zero
+// for unlimited
+//
+// Returns:
+// - *Quota: the file system
+// - error: error if the current consumption cannot be computed
+func NewQuota(dir Directory, maxBytes, maxInodes int64) (*Quota, error) {
+	q := &Quota{MaxBytes: maxBytes, MaxInodes: maxInodes, root: NewRoot(dir)}
+
+	if err := q.Refresh(); err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
+
+// Usage returns the current consumption of the directory.
+func (q *Quota) Usage() Usage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.usage
+}
+
+// Refresh computes the consumption of the directory again
+//
+// Returns:
+// - error: error if the directory cannot be read
+func (q *Quota) Refresh() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage, err := q.root.Dir.Usage()
+	if err != nil {
+		return fmt.Errorf("failed to compute quota usage: %w", err)
+	}
+
+	q.usage = usage
+
+	return nil
+}
+
+// Open implements FileSystem.
+func (q *Quota) Open(name string) (io.ReadCloser, error) {
+	return q.root.Open(name)
+}
+
+// WriteFile implements FileSystem, failing with an error matching
+// ErrQuotaExceeded when the file would exceed the budget.
+func (q *Quota) WriteFile(name string, data []byte, perm os.FileMode) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delta := Usage{Bytes: int64(len(data)), Files: 1}
+
+	info, err := q.root.Stat(name)
+	switch {
+	case err == nil:
+		delta.Files = 0
+		if info.Mode().IsRegular() {
+			delta.Bytes -= info.Size()
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	if err := q.check("write", name, delta); err != nil {
+		return err
+	}
+
+	if err := q.root.WriteFile(name, data, perm); err != nil {
+		return err
+	}
+
+	q.usage.add(delta)
+
+	return nil
+}
+
+// Stat implements FileSystem.
+func (q *Quota) Stat(name string) (os.FileInfo, error) {
+	return q.root.Stat(name)
+}
+
+// ReadDir implements FileSystem.
+func (q *Quota) ReadDir(name string) ([]os.DirEntry, error) {
+	return q.root.ReadDir(name)
+}
+
+// MkdirAll implements FileSystem, failing with an error matching
+// ErrQuotaExceeded when the missing directories would exceed the budget.
+func (q *Quota) MkdirAll(name string, perm os.FileMode) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	rel, err := rootRelative("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	var delta Usage
+	current := ""
+	for _, elem := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, elem)
+		if _, err := q.root.Stat(current); errors.Is(err, os.ErrNotExist) {
+			delta.Dirs++
+		}
+	}
+
+	if err := q.check("mkdir", name, delta); err != nil {
+		return err
+	}
+
+	if err := q.root.MkdirAll(name, perm); err != nil {
+		return err
+	}
+
+	q.usage.add(delta)
+
+	return nil
+}
+
+// Rename implements FileSystem.
+func (q *Quota) Rename(oldname, newname string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	replaced, err := q.lstat("rename", newname)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := q.root.Rename(oldname, newname); err != nil {
+		return err
+	}
+
+	if replaced != nil {
+		q.usage.add(usageOf(replaced, -1))
+	}
+
+	return nil
+}
+
+// Remove implements FileSystem.
+func (q *Quota) Remove(name string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	info, err := q.lstat("remove", name)
+	if err != nil {
+		return err
+	}
+
+	if err := q.root.Remove(name); err != nil {
+		return err
+	}
+
+	q.usage.add(usageOf(info, -1))
+
+	return nil
+}
+
+// Watch implements FileSystem.
+func (q *Quota) Watch(ctx context.Context, name string, changed func()) error {
+	return q.root.Watch(ctx, name, changed)
+}
+
+// check Returns an error if a change would exceed the budget
+func (q *Quota) check(op, name string, delta Usage) error {
+	usage := q.usage
+	usage.add(delta)
+
+	if q.MaxBytes > 0 && delta.Bytes > 0 && usage.Bytes > q.MaxBytes {
+		return &os.PathError{Op: op, Path: name, Err: fmt.Errorf("%w: %d of %d bytes", ErrQuotaExceeded, usage.Bytes, q.MaxBytes)}
+	}
+
+	if q.MaxInodes > 0 && delta.Inodes() > 0 && usage.Inodes() > q.MaxInodes {
+		return &os.PathError{Op: op, Path: name, Err: fmt.Errorf("%w: %d of %d inodes", ErrQuotaExceeded, usage.Inodes(), q.MaxInodes)}
+	}
+
+	return nil
+}
+
+// lstat Describes a path of the root without following its last symbolic link
+func (q *Quota) lstat(op, name string) (os.FileInfo, error) {
+	resolved, release, err := q.root.resolveName(op, name, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	info, err := os.Lstat(resolved)
+	if err != nil {
+		return nil, renamePathError(err, name)
+	}
+
+	return info, nil
+}
+
+// usageOf Returns the usage of a single file or directory, multiplied by a
+// sign
+func usageOf(info os.FileInfo, sign int64) Usage {
+	if info.IsDir() {
+		return Usage{Dirs: sign}
+	}
+
+	usage := Usage{Files: sign}
+	if info.Mode().IsRegular() {
+		usage.Bytes = sign * info.Size()
+	}
+
+	return usage
+}
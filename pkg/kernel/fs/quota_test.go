@@ -0,0 +1,53 @@
+package fs_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuota(t *testing.T) {
+	dir := fs.Directory(t.TempDir())
+	assert.NoError(t, os.WriteFile(string(dir.Join("existing.txt")), []byte("1234"), 0o644))
+
+	quota, err := fs.NewQuota(dir, 10, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, fs.Usage{Bytes: 4, Files: 1}, quota.Usage())
+
+	assert.NoError(t, quota.WriteFile("a.txt", []byte("123456"), 0o644))
+	assert.Equal(t, fs.Usage{Bytes: 10, Files: 2}, quota.Usage())
+
+	err = quota.WriteFile("b.txt", []byte("1"), 0o644)
+	assert.ErrorIs(t, err, fs.ErrQuotaExceeded)
+	assert.NoFileExists(t, string(dir.Join("b.txt")))
+
+	// Replacing a file only accounts for the size difference.
+	assert.NoError(t, quota.WriteFile("a.txt", []byte("12"), 0o644))
+	assert.Equal(t, fs.Usage{Bytes: 6, Files: 2}, quota.Usage())
+
+	assert.NoError(t, quota.MkdirAll("sub", 0o755))
+	err = quota.MkdirAll("x/y", 0o755)
+	assert.ErrorIs(t, err, fs.ErrQuotaExceeded)
+	assert.NoDirExists(t, string(dir.Sub("x")))
+
+	assert.NoError(t, quota.WriteFile("sub/c.txt", []byte("1"), 0o644))
+	err = quota.WriteFile("d.txt", nil, 0o644)
+	assert.ErrorIs(t, err, fs.ErrQuotaExceeded)
+	assert.Equal(t, fs.Usage{Bytes: 7, Files: 3, Dirs: 1}, quota.Usage())
+
+	assert.NoError(t, quota.Rename("a.txt", "existing.txt"))
+	assert.Equal(t, fs.Usage{Bytes: 3, Files: 2, Dirs: 1}, quota.Usage())
+
+	assert.NoError(t, quota.Remove("sub/c.txt"))
+	assert.NoError(t, quota.Remove("sub"))
+	assert.Equal(t, fs.Usage{Bytes: 2, Files: 1}, quota.Usage())
+
+	_, err = quota.Stat("../outside")
+	assert.ErrorIs(t, err, fs.ErrOutsideRoot)
+
+	assert.NoError(t, os.WriteFile(string(dir.Join("external.txt")), []byte("123"), 0o644))
+	assert.NoError(t, quota.Refresh())
+	assert.Equal(t, fs.Usage{Bytes: 5, Files: 2}, quota.Usage())
+}
@@ -0,0 +1,285 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrOutsideRoot is returned when a path designates a file outside of the
+// directory it is confined to.
+var ErrOutsideRoot = errors.New("path outside of root")
+
+// SafeJoin joins untrusted path elements onto the directory
+//
+// The result must stay inside the directory: absolute elements, ".." escapes
+// and symbolic links resolving outside of the directory are rejected. The
+// check is made when SafeJoin is called, a Root should be used when the
+// directory can be modified concurrently by untrusted parties.
+//
+// Parameters:
+// - untrusted: ...string - the elements to join, e.g. a user-provided name
+//
+// Returns:
+// - File: the joined path
+// - error: error matching ErrOutsideRoot if the path escapes the directory
+func (d Directory) SafeJoin(untrusted ...string) (File, error) {
+	name := filepath.Join(untrusted...)
+	for _, elem := range untrusted {
+		if isAbs(elem) {
+			name = elem
+			break
+		}
+	}
+
+	rel, err := rootRelative("join", name)
+	if err != nil {
+		return "", err
+	}
+
+	_, release, err := NewRoot(d).resolve("join", rel, true)
+	if err == nil {
+		release()
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	return d.Join(rel), nil
+}
+
+// Root is a FileSystem confined to a directory, like a chroot.
+//
+// Paths are relative to the directory. Absolute paths, ".." escapes and
+// symbolic links resolving outside of the directory fail with an error
+// matching ErrOutsideRoot. On Linux, paths are resolved one element at a time
+// from directory descriptors, so that a symbolic link swapped in concurrently
+// cannot lead outside of the directory. Absolute symbolic links pointing
+// inside the directory are followed.
+type Root struct {
+	Dir Directory
+	// PollInterval is the interval at which Watch checks the file. Defaults to
+	// one second.
+	PollInterval time.Duration
+}
+
+// NewRoot creates a file system confined to a directory
+//
+// Parameters:
+// - dir: Directory - the root of the file system
+//
+// Returns:
+// - *Root: the confined file system
+func NewRoot(dir Directory) *Root {
+	return &Root{Dir: dir}
+}
+
+// Open implements FileSystem.
+func (r *Root) Open(name string) (io.ReadCloser, error) {
+	return r.openFile("open", name, os.O_RDONLY, 0)
+}
+
+// WriteFile implements FileSystem.
+func (r *Root) WriteFile(name string, data []byte, perm os.FileMode) error {
+	file, err := r.openFile("write", name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// Stat implements FileSystem.
+func (r *Root) Stat(name string) (os.FileInfo, error) {
+	resolved, release, err := r.resolveName("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	info, err := os.Lstat(resolved)
+	if err != nil {
+		return nil, renamePathError(err, name)
+	}
+
+	return info, nil
+}
+
+// ReadDir implements FileSystem.
+func (r *Root) ReadDir(name string) ([]os.DirEntry, error) {
+	file, err := r.openFile("readdir", name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries, err := file.ReadDir(-1)
+	if err != nil {
+		return nil, renamePathError(err, name)
+	}
+
+	slices.SortFunc(entries, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	return entries, nil
+}
+
+// MkdirAll implements FileSystem.
+func (r *Root) MkdirAll(name string, perm os.FileMode) error {
+	rel, err := rootRelative("mkdir", name)
+	if err != nil {
+		return err
+	}
+
+	current := ""
+	for _, elem := range strings.Split(rel, string(filepath.Separator)) {
+		current = filepath.Join(current, elem)
+
+		resolved, release, err := r.resolve("mkdir", current, true)
+		if err != nil {
+			return err
+		}
+
+		err = os.Mkdir(resolved, perm)
+		release()
+
+		if errors.Is(err, os.ErrExist) {
+			if info, statErr := r.Stat(current); statErr == nil && info.IsDir() {
+				continue
+			}
+		}
+
+		if err != nil {
+			return renamePathError(err, name)
+		}
+	}
+
+	return nil
+}
+
+// Rename implements FileSystem.
+func (r *Root) Rename(oldname, newname string) error {
+	oldResolved, oldRelease, err := r.resolveName("rename", oldname, false)
+	if err != nil {
+		return err
+	}
+	defer oldRelease()
+
+	newResolved, newRelease, err := r.resolveName("rename", newname, false)
+	if err != nil {
+		return err
+	}
+	defer newRelease()
+
+	if err := os.Rename(oldResolved, newResolved); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: errors.Unwrap(err)}
+	}
+
+	return nil
+}
+
+// Remove implements FileSystem.
+func (r *Root) Remove(name string) error {
+	resolved, release, err := r.resolveName("remove", name, false)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	return renamePathError(os.Remove(resolved), name)
+}
+
+// Watch implements FileSystem by polling the modification time and size of
+// the file.
+func (r *Root) Watch(ctx context.Context, name string, changed func()) error {
+	interval := r.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	return poll(ctx, interval, func() (string, error) {
+		info, err := r.Stat(name)
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("failed to watch %s: %w", name, err)
+		}
+
+		return fmt.Sprintf("%d/%d/%v", info.ModTime().UnixNano(), info.Size(), info.Mode()), nil
+	}, changed)
+}
+
+// openFile Opens a file of the root, following symbolic links inside of it
+func (r *Root) openFile(op, name string, flag int, perm os.FileMode) (*os.File, error) {
+	resolved, release, err := r.resolveName(op, name, true)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	file, err := os.OpenFile(resolved, flag|noFollow, perm)
+	if err != nil {
+		return nil, renamePathError(err, name)
+	}
+
+	return file, nil
+}
+
+// resolveName Checks a name and resolves it into a path of the OS, the
+// release function must be called once the path is no longer used
+func (r *Root) resolveName(op, name string, follow bool) (string, func(), error) {
+	rel, err := rootRelative(op, name)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return r.resolve(op, rel, follow)
+}
+
+// rootRelative Cleans a name relative to a root, rejecting absolute names and
+// names escaping the root
+func rootRelative(op, name string) (string, error) {
+	if isAbs(name) {
+		return "", &os.PathError{Op: op, Path: name, Err: ErrOutsideRoot}
+	}
+
+	rel := filepath.Clean(filepath.FromSlash(name))
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", &os.PathError{Op: op, Path: name, Err: ErrOutsideRoot}
+	}
+
+	return rel, nil
+}
+
+// isAbs Reports whether a name is absolute, with either separator or a volume
+func isAbs(name string) bool {
+	return filepath.IsAbs(name) || filepath.VolumeName(name) != "" || strings.HasPrefix(filepath.ToSlash(name), "/")
+}
+
+// within Reports whether a path is inside of a directory
+func within(dir, name string) bool {
+	rel, err := filepath.Rel(dir, name)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// renamePathError Replaces the resolved path of an error with the name given
+// by the caller
+func renamePathError(err error, name string) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return &os.PathError{Op: pathErr.Op, Path: name, Err: pathErr.Err}
+	}
+
+	return err
+}
@@ -0,0 +1,163 @@
+//go:build linux
+
+package fs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// noFollow makes the opening of a symbolic link fail, so that a link swapped
+// in after the resolution is not followed.
+const noFollow = syscall.O_NOFOLLOW
+
+// maxSymlinks is the number of symbolic links followed before a resolution
+// fails, as done by the kernel.
+const maxSymlinks = 40
+
+// resolve Resolves a path relative to the root into a path of the OS
+//
+// The path is walked from a descriptor of the root, opening each directory
+// from the descriptor of its parent without following symbolic links. Links
+// are read and resolved in place, ".." elements pop the opened directories,
+// so that no element can lead outside of the root. The returned path designates
+// the last element through the descriptor of its parent, which stays open until
+// release is called.
+//
+// Parameters:
+// - op: string - the operation, for errors
+// - rel: string - the cleaned path, relative to the root
+// - follow: bool - whether a symbolic link as last element is resolved
+//
+// Returns:
+// - string: the path of the OS, valid until release is called
+// - func(): releases the descriptors, nil on error
+// - error: error matching ErrOutsideRoot if the path escapes the root
+func (r *Root) resolve(op, rel string, follow bool) (string, func(), error) {
+	root, err := os.OpenFile(string(r.Dir), os.O_RDONLY|syscall.O_DIRECTORY, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	stack := []*os.File{root}
+	release := func() {
+		for _, dir := range stack {
+			dir.Close()
+		}
+	}
+
+	fail := func(err error) (string, func(), error) {
+		release()
+		return "", nil, &os.PathError{Op: op, Path: rel, Err: err}
+	}
+
+	elems := splitPath(rel)
+	links := 0
+	for len(elems) > 0 {
+		elem := elems[0]
+		elems = elems[1:]
+
+		if elem == ".." {
+			if len(stack) == 1 {
+				return fail(ErrOutsideRoot)
+			}
+			stack[len(stack)-1].Close()
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		dir := stack[len(stack)-1]
+		last := len(elems) == 0
+
+		info, err := os.Lstat(fdPath(dir, elem))
+		if err != nil && !(last && os.IsNotExist(err)) {
+			return fail(unwrapPathError(err))
+		}
+
+		if err == nil && info.Mode()&os.ModeSymlink != 0 && (follow || !last) {
+			if links++; links > maxSymlinks {
+				return fail(syscall.ELOOP)
+			}
+
+			target, err := os.Readlink(fdPath(dir, elem))
+			if err != nil {
+				return fail(unwrapPathError(err))
+			}
+
+			if filepath.IsAbs(target) {
+				target, err = r.relative(target)
+				if err != nil {
+					return fail(err)
+				}
+				for _, dir := range stack[1:] {
+					dir.Close()
+				}
+				stack = stack[:1]
+			}
+
+			elems = append(splitPath(target), elems...)
+			continue
+		}
+
+		if last {
+			return fdPath(dir, elem), release, nil
+		}
+
+		fd, err := syscall.Openat(int(dir.Fd()), elem, syscall.O_RDONLY|syscall.O_DIRECTORY|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			return fail(err)
+		}
+		stack = append(stack, os.NewFile(uintptr(fd), elem))
+	}
+
+	return fdPath(stack[len(stack)-1], "."), release, nil
+}
+
+// relative Converts the absolute target of a symbolic link into a path
+// relative to the root, failing when it is outside of the root
+func (r *Root) relative(target string) (string, error) {
+	root, err := filepath.EvalSymlinks(string(r.Dir))
+	if err != nil {
+		return "", err
+	}
+
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	if !within(root, target) {
+		return "", ErrOutsideRoot
+	}
+
+	return filepath.Rel(root, target)
+}
+
+// fdPath Returns the path of an element of an opened directory
+func fdPath(dir *os.File, elem string) string {
+	return fmt.Sprintf("/proc/self/fd/%d/%s", dir.Fd(), elem)
+}
+
+// splitPath Splits a path into its elements, ignoring empty and "." ones
+func splitPath(name string) []string {
+	var elems []string
+	for _, elem := range strings.Split(name, string(filepath.Separator)) {
+		if elem != "" && elem != "." {
+			elems = append(elems, elem)
+		}
+	}
+
+	return elems
+}
+
+// unwrapPathError Returns the cause of a path error, hiding the resolved path
+func unwrapPathError(err error) error {
+	if pathErr, ok := err.(*os.PathError); ok {
+		return pathErr.Err
+	}
+
+	return err
+}
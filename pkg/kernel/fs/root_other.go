@@ -0,0 +1,68 @@
+//go:build !linux
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// noFollow is not supported on this system, symbolic links are checked when
+// the path is resolved.
+const noFollow = 0
+
+// resolve Resolves a path relative to the root into a path of the OS
+//
+// The symbolic links of the path are evaluated and checked to stay inside of
+// the root. The check is not atomic with the use of the path.
+//
+// Parameters:
+// - op: string - the operation, for errors
+// - rel: string - the cleaned path, relative to the root
+// - follow: bool - whether a symbolic link as last element is resolved
+//
+// Returns:
+// - string: the path of the OS
+// - func(): does nothing, nil on error
+// - error: error matching ErrOutsideRoot if the path escapes the root
+func (r *Root) resolve(op, rel string, follow bool) (string, func(), error) {
+	root, err := filepath.EvalSymlinks(string(r.Dir))
+	if err != nil {
+		return "", nil, err
+	}
+
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", nil, err
+	}
+
+	joined := filepath.Join(string(r.Dir), rel)
+
+	checked := joined
+	if !follow {
+		checked = filepath.Dir(joined)
+	}
+
+	for {
+		resolved, err := filepath.EvalSymlinks(checked)
+		if err == nil {
+			if resolved, err = filepath.Abs(resolved); err != nil {
+				return "", nil, err
+			}
+			if !within(root, resolved) {
+				return "", nil, &os.PathError{Op: op, Path: rel, Err: ErrOutsideRoot}
+			}
+			break
+		}
+
+		parent := filepath.Dir(checked)
+		if !errors.Is(err, os.ErrNotExist) || parent == checked {
+			return "", nil, &os.PathError{Op: op, Path: rel, Err: err}
+		}
+
+		checked = parent
+	}
+
+	return joined, func() {}, nil
+}
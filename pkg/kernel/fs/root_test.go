@@ -0,0 +1,78 @@
+package fs_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectorySafeJoin(t *testing.T) {
+	base := fs.Directory(t.TempDir())
+	dir := base.Sub("data")
+	assert.NoError(t, dir.Sub("users").MkdirAll(0o755))
+	assert.NoError(t, os.Symlink(string(base), string(dir.Join("escape"))))
+	assert.NoError(t, os.Symlink("users", string(dir.Join("inside"))))
+
+	file, err := dir.SafeJoin("users", "alice.json")
+	assert.NoError(t, err)
+	assert.Equal(t, dir.Join("users", "alice.json"), file)
+
+	file, err = dir.SafeJoin("inside", "new", "bob.json")
+	assert.NoError(t, err)
+	assert.Equal(t, dir.Join("inside", "new", "bob.json"), file)
+
+	for _, untrusted := range [][]string{
+		{"..", "secret"},
+		{"users", "../../secret"},
+		{"/etc/passwd"},
+		{"users", "/etc/passwd"},
+		{"escape", "secret"},
+	} {
+		_, err := dir.SafeJoin(untrusted...)
+		assert.ErrorIs(t, err, fs.ErrOutsideRoot, untrusted)
+	}
+}
+
+func TestRoot(t *testing.T) {
+	base := t.TempDir()
+	dir := filepath.Join(base, "root")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "conf"), 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(base, "secret"), []byte("secret"), 0o600))
+	assert.NoError(t, os.Symlink("../secret", filepath.Join(dir, "relative")))
+	assert.NoError(t, os.Symlink(filepath.Join(base, "secret"), filepath.Join(dir, "absolute")))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "conf"), filepath.Join(dir, "linked")))
+
+	root := fs.NewRoot(fs.Directory(dir))
+
+	assert.NoError(t, root.MkdirAll("conf/db", 0o755))
+	assert.NoError(t, root.WriteFile("linked/db/db.yaml", []byte("a: 1\n"), 0o600))
+
+	content, err := fs.ReadFile(root, "conf/db/db.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 1\n", string(content))
+
+	entries, err := root.ReadDir("conf")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	assert.NoError(t, root.Rename("conf/db/db.yaml", "conf/app.yaml"))
+	info, err := root.Stat("conf/app.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size())
+
+	for _, name := range []string{"../secret", "relative", "absolute", "/etc/passwd", "conf/../../secret"} {
+		_, err := fs.ReadFile(root, name)
+		assert.ErrorIs(t, err, fs.ErrOutsideRoot, name)
+		assert.ErrorIs(t, root.WriteFile(name, nil, 0o600), fs.ErrOutsideRoot, name)
+	}
+
+	assert.NoError(t, root.Remove("relative"))
+	_, err = os.Stat(filepath.Join(base, "secret"))
+	assert.NoError(t, err)
+
+	_, err = root.Stat("missing")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
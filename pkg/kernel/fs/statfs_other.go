@@ -0,0 +1,11 @@
+//go:build !(linux || darwin || freebsd)
+
+package fs
+
+import "errors"
+
+// statfs Returns an error, the capacity of file systems is not supported on
+// this system
+func statfs(path string) (Space, error) {
+	return Space{}, errors.ErrUnsupported
+}
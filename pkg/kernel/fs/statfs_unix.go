@@ -0,0 +1,21 @@
+//go:build linux || darwin || freebsd
+
+package fs
+
+import "syscall"
+
+// statfs Returns the capacity of the file system holding a path
+func statfs(path string) (Space, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return Space{}, err
+	}
+
+	size := uint64(stat.Bsize)
+
+	return Space{
+		Total:     uint64(stat.Blocks) * size,
+		Free:      uint64(stat.Bfree) * size,
+		Available: uint64(stat.Bavail) * size,
+	}, nil
+}
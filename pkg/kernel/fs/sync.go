@@ -0,0 +1,303 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SyncAction is the change made by Sync to a path of the destination.
+type SyncAction string
+
+const (
+	// SyncCreate creates a file, directory or symbolic link missing from the
+	// destination.
+	SyncCreate SyncAction = "create"
+	// SyncUpdate replaces a file or symbolic link differing from the source.
+	SyncUpdate SyncAction = "update"
+	// SyncDelete removes a path missing from the source.
+	SyncDelete SyncAction = "delete"
+)
+
+// SyncChange is a change made, or planned with DryRun, by Sync.
+type SyncChange struct {
+	// Path is the slash separated path of the change, relative to the
+	// directories.
+	Path   string
+	Action SyncAction
+}
+
+// SyncOptions configures Sync.
+type SyncOptions struct {
+	// Checksum compares the content of the files instead of their size and
+	// modification time.
+	Checksum bool
+	// Delete removes the paths of the destination missing from the source.
+	Delete bool
+	// DryRun reports the changes without making them.
+	DryRun bool
+	// Exclude skips the files and directories whose base name matches one of
+	// the filepath.Match patterns, in both directories. Excluded paths of the
+	// destination are never deleted.
+	Exclude []string
+}
+
+// Sync mirrors a source directory to a destination directory
+//
+// Files missing from the destination, or differing by their size, modification
+// time or permissions (their content with Checksum), are copied with atomic
+// writes, keeping their permissions and modification times, so that readers of
+// the destination never see a partial file. Unchanged files are not touched.
+//
+// Parameters:
+// - src: Directory - the directory to copy
+// - dst: Directory - the mirror, created if missing
+// - options: SyncOptions - the comparison, deletion and dry-run settings
+//
+// Returns:
+// - []SyncChange: the changes, sorted by path
+// - error: error if a directory cannot be read or a change cannot be made
+func Sync(src, dst Directory, options SyncOptions) ([]SyncChange, error) {
+	s := &syncer{src: src, dst: dst, options: options}
+
+	if err := s.copy(); err != nil {
+		return s.changes, fmt.Errorf("failed to sync directory: %w", err)
+	}
+
+	if options.Delete {
+		if err := s.delete(); err != nil {
+			return s.changes, fmt.Errorf("failed to sync directory: %w", err)
+		}
+	}
+
+	if !options.DryRun {
+		if err := s.restoreTimes(); err != nil {
+			return s.changes, fmt.Errorf("failed to sync directory: %w", err)
+		}
+	}
+
+	slices.SortFunc(s.changes, func(a, b SyncChange) int { return strings.Compare(a.Path, b.Path) })
+
+	return s.changes, nil
+}
+
+// syncer holds the state of a Sync call.
+type syncer struct {
+	src     Directory
+	dst     Directory
+	options SyncOptions
+	changes []SyncChange
+	dirs    []string
+}
+
+// copy Copies the changed entries of the source
+func (s *syncer) copy() error {
+	if !s.options.DryRun {
+		if err := s.dst.MkdirAll(0o755); err != nil {
+			return err
+		}
+	}
+
+	return filepath.WalkDir(string(s.src), func(name string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if name == string(s.src) {
+			return nil
+		}
+
+		if matchAny(entry.Name(), s.options.Exclude) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(string(s.src), name)
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		return s.copyEntry(rel, info)
+	})
+}
+
+// restoreTimes Sets the modification times of the copied directories, deepest
+// first, once their content is synced
+func (s *syncer) restoreTimes() error {
+	for i := len(s.dirs) - 1; i >= 0; i-- {
+		source, err := os.Stat(string(s.src.Sub(s.dirs[i])))
+		if err != nil {
+			return err
+		}
+
+		if err := os.Chtimes(string(s.dst.Sub(s.dirs[i])), source.ModTime(), source.ModTime()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyEntry Copies an entry of the source when it differs from the destination
+func (s *syncer) copyEntry(rel string, info os.FileInfo) error {
+	target := s.dst.Join(rel)
+
+	existing, err := os.Lstat(string(target))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	action := SyncCreate
+	if existing != nil {
+		action = SyncUpdate
+		if existing.Mode().Type() != info.Mode().Type() {
+			if !s.options.DryRun {
+				if err := Directory(target).RemoveAll(); err != nil {
+					return err
+				}
+			}
+		} else if same, err := s.same(rel, info, existing); err != nil || same {
+			if info.IsDir() {
+				s.dirs = append(s.dirs, rel)
+			}
+			return err
+		}
+	}
+
+	s.changes = append(s.changes, SyncChange{Path: filepath.ToSlash(rel), Action: action})
+	if s.options.DryRun {
+		return nil
+	}
+
+	switch {
+	case info.IsDir():
+		s.dirs = append(s.dirs, rel)
+		if err := os.Mkdir(string(target), info.Mode().Perm()); err != nil && !errors.Is(err, os.ErrExist) {
+			return err
+		}
+		return os.Chmod(string(target), info.Mode().Perm())
+	case info.Mode()&os.ModeSymlink != 0:
+		link, err := os.Readlink(string(s.src.Join(rel)))
+		if err != nil {
+			return err
+		}
+		if err := target.Remove(); err != nil {
+			return err
+		}
+		return os.Symlink(link, string(target))
+	case !info.Mode().IsRegular():
+		return nil
+	}
+
+	return s.copyFile(rel, info)
+}
+
+// same Reports whether an entry of the destination matches the source
+func (s *syncer) same(rel string, info, existing os.FileInfo) (bool, error) {
+	switch {
+	case info.IsDir():
+		return info.Mode().Perm() == existing.Mode().Perm(), nil
+	case info.Mode()&os.ModeSymlink != 0:
+		source, err := os.Readlink(string(s.src.Join(rel)))
+		if err != nil {
+			return false, err
+		}
+		target, err := os.Readlink(string(s.dst.Join(rel)))
+		return source == target, err
+	case info.Size() != existing.Size() || info.Mode().Perm() != existing.Mode().Perm():
+		return false, nil
+	case !s.options.Checksum:
+		return info.ModTime().Equal(existing.ModTime()), nil
+	}
+
+	source, err := s.src.Join(rel).Hash(SHA256)
+	if err != nil {
+		return false, err
+	}
+
+	target, err := s.dst.Join(rel).Hash(SHA256)
+
+	return source == target, err
+}
+
+// copyFile Copies a file atomically, keeping its permissions and time
+func (s *syncer) copyFile(rel string, info os.FileInfo) error {
+	source, err := s.src.Join(rel).Open()
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	writer, err := NewAtomicWriter(s.dst.Join(rel), AtomicOptions{Perm: info.Mode().Perm()})
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	if _, err := io.Copy(writer, source); err != nil {
+		return err
+	}
+
+	if err := writer.Commit(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(string(s.dst.Join(rel)), info.ModTime(), info.ModTime())
+}
+
+// delete Removes the entries of the destination missing from the source
+func (s *syncer) delete() error {
+	if _, err := os.Stat(string(s.dst)); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return filepath.WalkDir(string(s.dst), func(name string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if name == string(s.dst) {
+			return nil
+		}
+
+		if matchAny(entry.Name(), s.options.Exclude) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(string(s.dst), name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := os.Lstat(string(s.src.Join(rel))); !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		s.changes = append(s.changes, SyncChange{Path: filepath.ToSlash(rel), Action: SyncDelete})
+		if !s.options.DryRun {
+			if err := os.RemoveAll(name); err != nil {
+				return err
+			}
+		}
+
+		if entry.IsDir() {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+}
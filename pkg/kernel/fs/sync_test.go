@@ -0,0 +1,89 @@
+package fs_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSync(t *testing.T) {
+	src := fs.Directory(t.TempDir())
+	dst := fs.Directory(t.TempDir()).Sub("mirror")
+	assert.NoError(t, src.Sub("conf").MkdirAll(0o755))
+	assert.NoError(t, os.WriteFile(string(src.Join("conf", "app.yaml")), []byte("a: 1\n"), 0o640))
+	assert.NoError(t, os.WriteFile(string(src.Join("README")), []byte("readme"), 0o644))
+	assert.NoError(t, os.WriteFile(string(src.Join("build.tmp")), nil, 0o644))
+
+	options := fs.SyncOptions{Delete: true, Exclude: []string{"*.tmp"}}
+
+	changes, err := fs.Sync(src, dst, fs.SyncOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 4)
+	exists, err := dst.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	changes, err = fs.Sync(src, dst, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []fs.SyncChange{
+		{Path: "README", Action: fs.SyncCreate},
+		{Path: "conf", Action: fs.SyncCreate},
+		{Path: "conf/app.yaml", Action: fs.SyncCreate},
+	}, changes)
+
+	info, err := dst.Join("conf", "app.yaml").Stat()
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	changes, err = fs.Sync(src, dst, options)
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+
+	srcHash, err := src.Hash(fs.SHA256, "*.tmp")
+	assert.NoError(t, err)
+	dstHash, err := dst.Hash(fs.SHA256)
+	assert.NoError(t, err)
+	assert.Equal(t, srcHash, dstHash)
+
+	assert.NoError(t, os.WriteFile(string(src.Join("conf", "app.yaml")), []byte("a: 2\n"), 0o640))
+	assert.NoError(t, os.WriteFile(string(dst.Join("extra")), nil, 0o644))
+	assert.NoError(t, os.WriteFile(string(dst.Join("keep.tmp")), nil, 0o644))
+
+	changes, err = fs.Sync(src, dst, fs.SyncOptions{Delete: true, DryRun: true, Exclude: []string{"*.tmp"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []fs.SyncChange{
+		{Path: "conf/app.yaml", Action: fs.SyncUpdate},
+		{Path: "extra", Action: fs.SyncDelete},
+	}, changes)
+
+	changes, err = fs.Sync(src, dst, options)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 2)
+
+	content, err := dst.Join("conf", "app.yaml").ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, "a: 2\n", string(content))
+
+	_, err = os.Stat(string(dst.Join("keep.tmp")))
+	assert.NoError(t, err)
+}
+
+func TestSyncChecksum(t *testing.T) {
+	src := fs.Directory(t.TempDir())
+	dst := fs.Directory(t.TempDir())
+	assert.NoError(t, os.WriteFile(string(src.Join("app.yaml")), []byte("a: 1\n"), 0o644))
+	assert.NoError(t, os.WriteFile(string(dst.Join("app.yaml")), []byte("a: 1\n"), 0o644))
+	past := time.Now().Add(-time.Hour)
+	assert.NoError(t, os.Chtimes(string(dst.Join("app.yaml")), past, past))
+
+	changes, err := fs.Sync(src, dst, fs.SyncOptions{DryRun: true})
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+
+	changes, err = fs.Sync(src, dst, fs.SyncOptions{Checksum: true})
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+}
@@ -0,0 +1,305 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tempPrefix starts the names of the temporary files and directories, followed
+// by the identifier of the creating process, e.g. "fs-tmp-1234-upload-42.json".
+const tempPrefix = "fs-tmp-"
+
+const (
+	// defaultJanitorMaxAge is the time without modification after which
+	// temporary files are removed even when their process is running.
+	defaultJanitorMaxAge = 24 * time.Hour
+	// defaultJanitorInterval is the interval between two runs of a janitor.
+	defaultJanitorInterval = time.Hour
+)
+
+// TB is the part of testing.TB used to clean up temporary files when a test
+// ends.
+type TB interface {
+	Helper()
+	Fatalf(format string, args ...any)
+	Cleanup(func())
+}
+
+// TempDir creates a temporary directory
+//
+// The name of the directory carries the identifier of the process, so that a
+// Janitor can remove it if the process dies without calling Cleanup.
+//
+// Parameters:
+// - pattern: string - the name of the directory, its last "*" being replaced
+// by a random string, e.g. "upload-*"
+//
+// Returns:
+// - Directory: the created directory, in the temporary directory of the system
+// - error: error if the directory cannot be created
+func TempDir(pattern string) (Directory, error) {
+	dir, err := os.MkdirTemp("", tempName(pattern))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+
+	return Directory(dir), nil
+}
+
+// TempFile creates an empty temporary file
+//
+// The name of the file carries the identifier of the process, so that a
+// Janitor can remove it if the process dies without calling Cleanup.
+//
+// Parameters:
+// - dir: Directory - the directory of the file, the temporary directory of the
+// system when empty
+// - pattern: string - the name of the file, its last "*" being replaced by a
+// random string, e.g. "upload-*.json"
+//
+// Returns:
+// - File: the created file
+// - error: error if the file cannot be created
+func TempFile(dir Directory, pattern string) (File, error) {
+	file, err := os.CreateTemp(string(dir), tempName(pattern))
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	return File(file.Name()), nil
+}
+
+// TempDirFor creates a temporary directory removed when a test ends, failing
+// the test if it cannot be created.
+func TempDirFor(tb TB, pattern string) Directory {
+	tb.Helper()
+
+	dir, err := TempDir(pattern)
+	if err != nil {
+		tb.Fatalf("%v", err)
+	}
+	tb.Cleanup(func() { _ = dir.Cleanup() })
+
+	return dir
+}
+
+// TempFileFor creates a temporary file removed when a test ends, failing the
+// test if it cannot be created.
+func TempFileFor(tb TB, dir Directory, pattern string) File {
+	tb.Helper()
+
+	file, err := TempFile(dir, pattern)
+	if err != nil {
+		tb.Fatalf("%v", err)
+	}
+	tb.Cleanup(func() { _ = file.Cleanup() })
+
+	return file
+}
+
+// Cleanup removes a temporary directory created by TempDir and its content
+//
+// Directories not named by TempDir are left untouched, so that a wrong path
+// cannot remove unrelated data.
+//
+// Returns:
+// - error: error if the directory is not temporary or cannot be removed
+func (d Directory) Cleanup() error {
+	if _, ok := tempPID(d.Base()); !ok {
+		return fmt.Errorf("failed to clean up directory: %s is not a temporary directory", d)
+	}
+
+	return d.RemoveAll()
+}
+
+// CleanupAfter removes the temporary directory once a context is done
+//
+// Parameters:
+// - ctx: context.Context - the scope of the directory
+//
+// Returns:
+// - func() bool: cancels the removal, returning false if already started
+func (d Directory) CleanupAfter(ctx context.Context) func() bool {
+	return context.AfterFunc(ctx, func() { _ = d.Cleanup() })
+}
+
+// Cleanup removes a temporary file created by TempFile
+//
+// Files not named by TempFile are left untouched, so that a wrong path cannot
+// remove unrelated data.
+//
+// Returns:
+// - error: error if the file is not temporary or cannot be removed
+func (f File) Cleanup() error {
+	if _, ok := tempPID(f.Base()); !ok {
+		return fmt.Errorf("failed to clean up file: %s is not a temporary file", f)
+	}
+
+	return f.Remove()
+}
+
+// CleanupAfter removes the temporary file once a context is done
+//
+// Parameters:
+// - ctx: context.Context - the scope of the file
+//
+// Returns:
+// - func() bool: cancels the removal, returning false if already started
+func (f File) CleanupAfter(ctx context.Context) func() bool {
+	return context.AfterFunc(ctx, func() { _ = f.Cleanup() })
+}
+
+// Janitor removes the temporary files and directories left behind by
+// processes that died without cleaning them up.
+//
+// An entry is stale when the process named by its name is no longer running,
+// or, since process identifiers are reused, when nothing in it has been
+// modified for MaxAge: the newest modification time of a directory tree is
+// used, so a directory whose files are still being written is kept. Entries
+// not created by TempDir or TempFile are never removed.
+type Janitor struct {
+	// Dir is the directory to clean. Defaults to the temporary directory of the
+	// system.
+	Dir Directory
+	// MaxAge is the time without modification after which entries are removed
+	// even when their process is running. Defaults to 24 hours.
+	MaxAge time.Duration
+	// Interval is the interval between two runs of Run. Defaults to one hour.
+	Interval time.Duration
+}
+
+// Clean removes the stale temporary entries of the directory
+//
+// Returns:
+// - []string: the paths of the removed entries
+// - error: error if the directory cannot be read or an entry cannot be removed
+func (j *Janitor) Clean() ([]string, error) {
+	dir := j.Dir
+	if dir == "" {
+		dir = Directory(os.TempDir())
+	}
+
+	maxAge := j.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultJanitorMaxAge
+	}
+
+	entries, err := os.ReadDir(string(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to clean directory: %w", err)
+	}
+
+	var removed []string
+	var errs []error
+	for _, entry := range entries {
+		pid, ok := tempPID(entry.Name())
+		if !ok {
+			continue
+		}
+
+		path := dir.Sub(entry.Name())
+		if processAlive(pid) && time.Since(modTime(string(path))) < maxAge {
+			continue
+		}
+
+		if err := path.RemoveAll(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		removed = append(removed, string(path))
+	}
+
+	return removed, errors.Join(errs...)
+}
+
+// Run cleans the directory every Interval until the context is done
+//
+// Failed removals are retried on the next run.
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the janitor
+//
+// Returns:
+// - error: the context error once the janitor stops
+func (j *Janitor) Run(ctx context.Context) error {
+	interval := j.Interval
+	if interval <= 0 {
+		interval = defaultJanitorInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = j.Clean()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// modTime Returns the newest modification time of a file or a directory tree,
+// the current time when it cannot be read so that the entry is kept
+func modTime(path string) time.Time {
+	var newest time.Time
+	err := filepath.WalkDir(path, func(_ string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+
+		return nil
+	})
+	if err != nil {
+		return time.Now()
+	}
+
+	return newest
+}
+
+// tempName Returns the pattern of a temporary entry created by the process
+func tempName(pattern string) string {
+	return tempPrefix + strconv.Itoa(os.Getpid()) + "-" + pattern
+}
+
+// tempPID Returns the identifier of the process that created a temporary entry
+func tempPID(name string) (int, bool) {
+	rest, ok := strings.CutPrefix(name, tempPrefix)
+	if !ok {
+		return 0, false
+	}
+
+	digits, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(digits)
+	if err != nil || pid <= 0 {
+		return 0, false
+	}
+
+	return pid, true
+}
@@ -0,0 +1,91 @@
+package fs_test
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTempDir(t *testing.T) {
+	dir, err := fs.TempDir("upload-*")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(dir.Base(), "fs-tmp-"+strconv.Itoa(os.Getpid())+"-upload-"))
+
+	file, err := fs.TempFile(dir, "part-*.json")
+	assert.NoError(t, err)
+	assert.Equal(t, ".json", file.Ext())
+	assert.Equal(t, dir, file.Dir())
+
+	assert.NoError(t, file.Cleanup())
+	assert.NoError(t, dir.Cleanup())
+
+	exists, err := dir.Exists()
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.ErrorContains(t, fs.Directory(t.TempDir()).Cleanup(), "not a temporary directory")
+}
+
+func TestTempCleanupAfter(t *testing.T) {
+	file := fs.TempFileFor(t, fs.Directory(t.TempDir()), "job-*")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	file.CleanupAfter(ctx)
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		exists, err := file.Exists()
+		return err == nil && !exists
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestJanitor(t *testing.T) {
+	dir := fs.Directory(t.TempDir())
+
+	live := fs.TempDirFor(t, "live-*")
+	assert.NoError(t, os.Rename(string(live), string(dir.Sub(live.Base()))))
+	live = dir.Sub(live.Base())
+
+	dead := dir.Sub("fs-tmp-99999999-dead-1")
+	assert.NoError(t, dead.MkdirAll(0o755))
+
+	old := fs.TempFileFor(t, dir, "old-*")
+	past := time.Now().Add(-48 * time.Hour)
+	assert.NoError(t, os.Chtimes(string(old), past, past))
+
+	// Old directories are kept while their content is modified.
+	active := fs.TempDirFor(t, "active-*")
+	assert.NoError(t, os.Rename(string(active), string(dir.Sub(active.Base()))))
+	active = dir.Sub(active.Base())
+	assert.NoError(t, active.Sub("nested").MkdirAll(0o755))
+	assert.NoError(t, os.WriteFile(string(active.Sub("nested").Join("data")), nil, 0o600))
+	for _, path := range []string{string(active), string(active.Sub("nested"))} {
+		assert.NoError(t, os.Chtimes(path, past, past))
+	}
+
+	idle := fs.TempDirFor(t, "idle-*")
+	assert.NoError(t, os.Rename(string(idle), string(dir.Sub(idle.Base()))))
+	idle = dir.Sub(idle.Base())
+	assert.NoError(t, os.WriteFile(string(idle.Join("data")), nil, 0o600))
+	for _, path := range []string{string(idle.Join("data")), string(idle)} {
+		assert.NoError(t, os.Chtimes(path, past, past))
+	}
+
+	unrelated := dir.Join("notes.txt")
+	assert.NoError(t, os.WriteFile(string(unrelated), nil, 0o600))
+
+	removed, err := (&fs.Janitor{Dir: dir}).Clean()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{string(dead), string(old), string(idle)}, removed)
+
+	for _, path := range []string{string(live), string(active), string(unrelated)} {
+		_, err := os.Stat(path)
+		assert.NoError(t, err)
+	}
+}
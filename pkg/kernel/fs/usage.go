@@ -0,0 +1,131 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// Usage is the space used by a directory tree.
+type Usage struct {
+	// Bytes is the total size of the regular files.
+	Bytes int64
+	// Files is the number of files, including symbolic links.
+	Files int64
+	// Dirs is the number of subdirectories.
+	Dirs int64
+}
+
+// Inodes returns the number of files and subdirectories.
+func (u Usage) Inodes() int64 {
+	return u.Files + u.Dirs
+}
+
+// add Adds the usage of a part of a tree
+func (u *Usage) add(other Usage) {
+	u.Bytes += other.Bytes
+	u.Files += other.Files
+	u.Dirs += other.Dirs
+}
+
+// Space is the capacity of a file system.
+type Space struct {
+	// Total is the size of the file system in bytes.
+	Total uint64
+	// Free is the number of free bytes.
+	Free uint64
+	// Available is the number of free bytes usable by unprivileged processes.
+	Available uint64
+}
+
+// FreeSpace returns the capacity of the file system holding a directory
+//
+// Parameters:
+// - dir: Directory - a directory of the file system
+//
+// Returns:
+// - Space: the total, free and available bytes
+// - error: error if the file system cannot be queried
+func FreeSpace(dir Directory) (Space, error) {
+	space, err := statfs(string(dir))
+	if err != nil {
+		return Space{}, fmt.Errorf("failed to get free space: %w", err)
+	}
+
+	return space, nil
+}
+
+// Usage returns the space used by the directory and its subdirectories
+//
+// Subdirectories are scanned concurrently. Entries removed during the scan are
+// ignored and symbolic links are not followed.
+//
+// Returns:
+// - Usage: the size and number of files and subdirectories
+// - error: error if a directory cannot be read
+func (d Directory) Usage() (Usage, error) {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total Usage
+		errs  []error
+	)
+
+	slots := make(chan struct{}, runtime.GOMAXPROCS(0))
+
+	var scan func(dir string)
+	scan = func(dir string) {
+		defer wg.Done()
+
+		entries, err := os.ReadDir(dir)
+		if err != nil && !(errors.Is(err, os.ErrNotExist) && dir != string(d)) {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			return
+		}
+
+		var usage Usage
+		for _, entry := range entries {
+			name := filepath.Join(dir, entry.Name())
+			if entry.IsDir() {
+				usage.Dirs++
+				wg.Add(1)
+				select {
+				case slots <- struct{}{}:
+					go func() {
+						defer func() { <-slots }()
+						scan(name)
+					}()
+				default:
+					scan(name)
+				}
+				continue
+			}
+
+			usage.Files++
+			if entry.Type().IsRegular() {
+				if info, err := entry.Info(); err == nil {
+					usage.Bytes += info.Size()
+				}
+			}
+		}
+
+		mu.Lock()
+		total.add(usage)
+		mu.Unlock()
+	}
+
+	wg.Add(1)
+	scan(string(d))
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return Usage{}, fmt.Errorf("failed to compute directory usage: %w", err)
+	}
+
+	return total, nil
+}
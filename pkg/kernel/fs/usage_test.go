@@ -0,0 +1,47 @@
+package fs_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectoryUsage(t *testing.T) {
+	dir := fs.Directory(t.TempDir())
+	assert.NoError(t, dir.Sub("a", "b").MkdirAll(0o755))
+	assert.NoError(t, dir.Sub("c").MkdirAll(0o755))
+	assert.NoError(t, os.WriteFile(string(dir.Join("root.txt")), []byte("12345"), 0o644))
+	assert.NoError(t, os.WriteFile(string(dir.Join("a", "a.txt")), []byte("123"), 0o644))
+	assert.NoError(t, os.WriteFile(string(dir.Join("a", "b", "b.txt")), []byte("1234567890"), 0o644))
+	assert.NoError(t, os.Symlink("root.txt", string(dir.Join("c", "link"))))
+
+	usage, err := dir.Usage()
+	assert.NoError(t, err)
+	assert.Equal(t, fs.Usage{Bytes: 18, Files: 4, Dirs: 3}, usage)
+	assert.Equal(t, int64(7), usage.Inodes())
+
+	usage, err = fs.Directory(t.TempDir()).Usage()
+	assert.NoError(t, err)
+	assert.Zero(t, usage)
+
+	_, err = dir.Sub("missing").Usage()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestFreeSpace(t *testing.T) {
+	space, err := fs.FreeSpace(fs.Directory(t.TempDir()))
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("statfs is not supported")
+	}
+
+	assert.NoError(t, err)
+	assert.NotZero(t, space.Total)
+	assert.LessOrEqual(t, space.Available, space.Free)
+	assert.LessOrEqual(t, space.Free, space.Total)
+
+	_, err = fs.FreeSpace(fs.Directory(t.TempDir()).Sub("missing"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
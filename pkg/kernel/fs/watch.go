@@ -0,0 +1,340 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Op is a set of file operations reported by Watch.
+type Op uint32
+
+const (
+	// Create reports a new file or directory.
+	Create Op = 1 << iota
+	// Write reports a modified or replaced file.
+	Write
+	// Remove reports a removed file or directory.
+	Remove
+	// Rename reports a file or directory moved away from its path.
+	Rename
+	// Chmod reports changed permissions or attributes.
+	Chmod
+)
+
+// opNames are the names of the operations, in display order.
+var opNames = []struct {
+	op   Op
+	name string
+}{
+	{Create, "CREATE"},
+	{Write, "WRITE"},
+	{Remove, "REMOVE"},
+	{Rename, "RENAME"},
+	{Chmod, "CHMOD"},
+}
+
+// Has reports whether the set holds any of the given operations.
+func (o Op) Has(op Op) bool {
+	return o&op != 0
+}
+
+// String returns the names of the operations, e.g. "CREATE|WRITE".
+func (o Op) String() string {
+	var names []string
+	for _, entry := range opNames {
+		if o.Has(entry.op) {
+			names = append(names, entry.name)
+		}
+	}
+
+	return strings.Join(names, "|")
+}
+
+// Event is a change reported by Watch.
+type Event struct {
+	// Path is the path of the changed file, joined to the watched directory.
+	Path string
+	Op   Op
+}
+
+// String returns the operations and the path of the event.
+func (e Event) String() string {
+	return e.Op.String() + " " + e.Path
+}
+
+// WatchOptions configures Watch.
+type WatchOptions struct {
+	// Recursive watches the subdirectories of a watched directory.
+	Recursive bool
+	// Include keeps only the events of the files whose base name matches one of
+	// the filepath.Match patterns, e.g. "*.yaml". Every file is kept when empty.
+	Include []string
+	// Exclude drops the events of the files whose base name matches one of the
+	// patterns, e.g. ".*.swp".
+	Exclude []string
+	// Ops keeps only the given operations. Every operation is kept when zero.
+	Ops Op
+	// Debounce coalesces the events received until no event has been received
+	// for the duration, reporting each path once. Events are reported as soon
+	// as received when zero.
+	Debounce time.Duration
+	// Poll watches by scanning the files instead of using the notifications of
+	// the system.
+	Poll bool
+	// PollInterval is the interval between two scans when polling. Defaults to
+	// one second.
+	PollInterval time.Duration
+}
+
+// watcher holds the state of a Watch call.
+type watcher struct {
+	root    string
+	file    string
+	options WatchOptions
+	handle  func(Event)
+	pending map[string]Op
+}
+
+// Watch reports the changes of a file or of the content of a directory
+//
+// The notifications of the system are used on Linux (inotify), with a fallback
+// on polling when they are unavailable, e.g. on other systems or when the
+// watch limit is reached. A file is watched through its directory, so that
+// editors saving by writing a temporary file renamed over the file report a
+// Write rather than the file disappearing. Polling cannot detect renames,
+// which are reported as Remove.
+//
+// With Debounce, the operations of a path are merged: a path removed and
+// created again is reported as Write, and a file created and removed within
+// the period is not reported.
+//
+// Parameters:
+// - ctx: context.Context - controls the lifetime of the watch
+// - target: File or Directory - the file or directory to watch
+// - options: WatchOptions - the filters, recursion and debouncing
+// - handle: func(Event) - called for each event, from the calling goroutine
+//
+// Returns:
+// - error: error matching os.ErrNotExist if the watched directory is removed
+// while watched with notifications, or the context error once watching stops
+func Watch[T File | Directory](ctx context.Context, target T, options WatchOptions, handle func(Event)) error {
+	w := &watcher{options: options, handle: handle}
+	switch target := any(target).(type) {
+	case File:
+		w.root, w.file = filepath.Clean(string(target.Dir())), target.Base()
+	case Directory:
+		w.root = filepath.Clean(string(target))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	backend := w.poll
+	if !options.Poll {
+		if notify, err := w.notifier(); err == nil {
+			backend = notify
+		}
+	}
+
+	events := make(chan Event, 64)
+	done := make(chan error, 1)
+	go func() { done <- backend(ctx, events) }()
+
+	var fire <-chan time.Time
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-fire:
+			w.flush()
+			fire = nil
+		case event := <-events:
+			if !w.match(event.Path) {
+				continue
+			}
+
+			if w.options.Debounce <= 0 {
+				w.emit(event)
+				continue
+			}
+
+			if w.pending == nil {
+				w.pending = map[string]Op{}
+			}
+			w.pending[event.Path] |= event.Op
+
+			if timer == nil {
+				timer = time.NewTimer(w.options.Debounce)
+			} else {
+				timer.Reset(w.options.Debounce)
+			}
+			fire = timer.C
+		}
+	}
+}
+
+// match Reports whether the events of a path are reported
+func (w *watcher) match(name string) bool {
+	if w.file != "" && name != filepath.Join(w.root, w.file) {
+		return false
+	}
+
+	base := filepath.Base(name)
+	if matchAny(base, w.options.Exclude) {
+		return false
+	}
+
+	return len(w.options.Include) == 0 || matchAny(base, w.options.Include)
+}
+
+// emit Reports an event, keeping the selected operations
+func (w *watcher) emit(event Event) {
+	if w.options.Ops != 0 {
+		event.Op &= w.options.Ops
+	}
+
+	if event.Op != 0 {
+		w.handle(event)
+	}
+}
+
+// flush Reports the coalesced events, sorted by path
+func (w *watcher) flush() {
+	paths := make([]string, 0, len(w.pending))
+	for name := range w.pending {
+		paths = append(paths, name)
+	}
+	slices.Sort(paths)
+
+	for _, name := range paths {
+		op := w.pending[name]
+
+		_, err := os.Lstat(name)
+		exists := err == nil
+
+		switch {
+		case exists && op.Has(Remove|Rename):
+			op = op&^(Create|Remove|Rename) | Write
+		case !exists && op.Has(Create):
+			continue
+		case !exists:
+			op &^= Write | Chmod
+		}
+
+		w.emit(Event{Path: name, Op: op})
+	}
+
+	clear(w.pending)
+}
+
+// poll Reports the changes found by scanning the watched files periodically
+func (w *watcher) poll(ctx context.Context, events chan<- Event) error {
+	interval := w.options.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := w.snapshot()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		next := w.snapshot()
+		for _, event := range diff(last, next) {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		last = next
+	}
+}
+
+// snapshot Describes the watched files, by path
+func (w *watcher) snapshot() map[string]os.FileInfo {
+	files := map[string]os.FileInfo{}
+	if w.file != "" {
+		name := filepath.Join(w.root, w.file)
+		if info, err := os.Lstat(name); err == nil {
+			files[name] = info
+		}
+		return files
+	}
+
+	w.scan(w.root, files)
+
+	return files
+}
+
+// scan Describes the files of a directory, recursively when requested
+func (w *watcher) scan(dir string, files map[string]os.FileInfo) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		name := filepath.Join(dir, entry.Name())
+		files[name] = info
+
+		if entry.IsDir() && w.options.Recursive {
+			w.scan(name, files)
+		}
+	}
+}
+
+// diff Returns the events turning a snapshot into another, sorted by path
+func diff(last, next map[string]os.FileInfo) []Event {
+	var events []Event
+	for name, info := range next {
+		previous, ok := last[name]
+		if !ok {
+			events = append(events, Event{Path: name, Op: Create})
+			continue
+		}
+
+		var op Op
+		if !info.IsDir() && (!info.ModTime().Equal(previous.ModTime()) || info.Size() != previous.Size()) {
+			op |= Write
+		}
+		if info.Mode() != previous.Mode() {
+			op |= Chmod
+		}
+		if op != 0 {
+			events = append(events, Event{Path: name, Op: op})
+		}
+	}
+
+	for name := range last {
+		if _, ok := next[name]; !ok {
+			events = append(events, Event{Path: name, Op: Remove})
+		}
+	}
+
+	slices.SortFunc(events, func(a, b Event) int { return strings.Compare(a.Path, b.Path) })
+
+	return events
+}
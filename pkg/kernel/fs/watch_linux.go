@@ -0,0 +1,207 @@
+//go:build linux
+
+package fs
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// inotifyMask is the set of inotify events watched on each directory.
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_DELETE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF |
+	syscall.IN_ONLYDIR
+
+// inotify watches directories with the inotify API of Linux.
+type inotify struct {
+	w     *watcher
+	fd    int
+	dirs  map[int32]string
+	known map[string]bool
+}
+
+// notifier Sets up the inotify watches of the watched directories
+//
+// Returns:
+// - func(context.Context, chan<- Event) error: the backend reporting the events
+// - error: error if inotify cannot be used, e.g. when the watch limit is reached
+func (w *watcher) notifier() (func(context.Context, chan<- Event) error, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &inotify{w: w, fd: fd, dirs: map[int32]string{}, known: map[string]bool{}}
+	if err := n.add(w.root); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	for name, info := range w.snapshot() {
+		n.known[name] = true
+
+		if info.IsDir() && w.options.Recursive && w.file == "" {
+			if err := n.add(name); err != nil {
+				syscall.Close(fd)
+				return nil, err
+			}
+		}
+	}
+
+	return n.run, nil
+}
+
+// add Watches a directory
+func (n *inotify) add(dir string) error {
+	wd, err := syscall.InotifyAddWatch(n.fd, dir, inotifyMask)
+	if err != nil {
+		return err
+	}
+
+	n.dirs[int32(wd)] = dir
+
+	return nil
+}
+
+// run Reads and reports the inotify events until the context is done
+func (n *inotify) run(ctx context.Context, events chan<- Event) error {
+	file := os.NewFile(uintptr(n.fd), "inotify")
+	defer file.Close()
+
+	stop := context.AfterFunc(ctx, func() { file.Close() })
+	defer stop()
+
+	send := func(event Event) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	buffer := make([]byte, 64*1024)
+	for {
+		count, err := file.Read(buffer)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("failed to watch directory: %w", err)
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= count; {
+			wd := int32(binary.NativeEndian.Uint32(buffer[offset:]))
+			mask := binary.NativeEndian.Uint32(buffer[offset+4:])
+			length := int(binary.NativeEndian.Uint32(buffer[offset+12:]))
+
+			start := offset + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buffer[start:start+length]), "\x00")
+			offset = start + length
+
+			if err := n.handle(wd, mask, name, send); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// handle Translates an inotify event into the events of Watch
+func (n *inotify) handle(wd int32, mask uint32, name string, send func(Event) bool) error {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		clear(n.known)
+		for name := range n.w.snapshot() {
+			n.known[name] = true
+		}
+		return nil
+	}
+
+	dir, ok := n.dirs[wd]
+	if !ok {
+		return nil
+	}
+
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(n.dirs, wd)
+		return nil
+	}
+
+	if mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 {
+		if dir == n.w.root {
+			return fmt.Errorf("failed to watch directory: %w", os.ErrNotExist)
+		}
+		return nil
+	}
+
+	name = filepath.Join(dir, name)
+	switch {
+	case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		op := Create
+		if n.known[name] {
+			op = Write
+		}
+		n.known[name] = true
+		send(Event{Path: name, Op: op})
+
+		if mask&syscall.IN_ISDIR != 0 && n.w.options.Recursive && n.w.file == "" {
+			n.addTree(name, send)
+		}
+	case mask&syscall.IN_MODIFY != 0:
+		n.known[name] = true
+		send(Event{Path: name, Op: Write})
+	case mask&syscall.IN_ATTRIB != 0:
+		send(Event{Path: name, Op: Chmod})
+	case mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+		op := Remove
+		if mask&syscall.IN_MOVED_FROM != 0 {
+			op = Rename
+		}
+		n.forget(name)
+		send(Event{Path: name, Op: op})
+	}
+
+	return nil
+}
+
+// addTree Watches a new directory and reports the files created in it before
+// it was watched
+func (n *inotify) addTree(dir string, send func(Event) bool) {
+	if err := n.add(dir); err != nil {
+		return
+	}
+
+	files := map[string]os.FileInfo{}
+	n.w.scan(dir, files)
+
+	for name, info := range files {
+		if info.IsDir() {
+			_ = n.add(name)
+		}
+
+		if !n.known[name] {
+			n.known[name] = true
+			send(Event{Path: name, Op: Create})
+		}
+	}
+}
+
+// forget Drops the state of a removed path and of its content
+func (n *inotify) forget(name string) {
+	for known := range n.known {
+		if known == name || strings.HasPrefix(known, name+string(filepath.Separator)) {
+			delete(n.known, known)
+		}
+	}
+
+	for wd, dir := range n.dirs {
+		if dir == name || strings.HasPrefix(dir, name+string(filepath.Separator)) {
+			_, _ = syscall.InotifyRmWatch(n.fd, uint32(wd))
+			delete(n.dirs, wd)
+		}
+	}
+}
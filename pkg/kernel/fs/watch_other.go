@@ -0,0 +1,14 @@
+//go:build !linux
+
+package fs
+
+import (
+	"context"
+	"errors"
+)
+
+// notifier Returns an error, the system notifications are not supported on
+// this system and Watch polls instead
+func (w *watcher) notifier() (func(context.Context, chan<- Event) error, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,97 @@
+package fs_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kistunium/sdk/pkg/kernel/fs"
+	"github.com/stretchr/testify/assert"
+)
+
+// watch starts watching a target and returns the reported events.
+func watch[T fs.File | fs.Directory](t *testing.T, target T, options fs.WatchOptions) <-chan fs.Event {
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan fs.Event, 64)
+	done := make(chan error)
+	go func() {
+		done <- fs.Watch(ctx, target, options, func(event fs.Event) { events <- event })
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+
+	time.Sleep(100 * time.Millisecond)
+
+	return events
+}
+
+// next returns the next event, failing after a second.
+func next(t *testing.T, events <-chan fs.Event) fs.Event {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+		return fs.Event{}
+	}
+}
+
+func TestWatchDirectory(t *testing.T) {
+	for name, poll := range map[string]bool{"notify": false, "poll": true} {
+		t.Run(name, func(t *testing.T) {
+			dir := fs.Directory(t.TempDir())
+			assert.NoError(t, dir.Sub("sub").MkdirAll(0o755))
+
+			events := watch(t, dir, fs.WatchOptions{
+				Recursive:    true,
+				Include:      []string{"*.yaml"},
+				Ops:          fs.Create | fs.Remove,
+				Poll:         poll,
+				PollInterval: 20 * time.Millisecond,
+			})
+
+			assert.NoError(t, os.WriteFile(string(dir.Join("notes.txt")), nil, 0o600))
+			assert.NoError(t, os.WriteFile(string(dir.Join("sub", "app.yaml")), []byte("a: 1\n"), 0o600))
+			assert.Equal(t, fs.Event{Path: string(dir.Join("sub", "app.yaml")), Op: fs.Create}, next(t, events))
+
+			assert.NoError(t, os.Remove(string(dir.Join("sub", "app.yaml"))))
+			assert.Equal(t, fs.Event{Path: string(dir.Join("sub", "app.yaml")), Op: fs.Remove}, next(t, events))
+		})
+	}
+}
+
+func TestWatchFileRenamedOver(t *testing.T) {
+	dir := fs.Directory(t.TempDir())
+	file := dir.Join("app.yaml")
+	assert.NoError(t, os.WriteFile(string(file), []byte("a: 1\n"), 0o600))
+
+	events := watch(t, file, fs.WatchOptions{Debounce: 50 * time.Millisecond})
+
+	temp := filepath.Join(string(dir), ".app.yaml.swp")
+	assert.NoError(t, os.WriteFile(temp, []byte("a: 2\n"), 0o600))
+	assert.NoError(t, os.Rename(string(file), string(file)+"~"))
+	assert.NoError(t, os.Rename(temp, string(file)))
+
+	assert.Equal(t, fs.Event{Path: string(file), Op: fs.Write}, next(t, events))
+
+	assert.NoError(t, os.Remove(string(file)))
+	assert.Equal(t, fs.Event{Path: string(file), Op: fs.Remove}, next(t, events))
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected event %s", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestOpString(t *testing.T) {
+	assert.Equal(t, "CREATE|WRITE", (fs.Create | fs.Write).String())
+	assert.Equal(t, "REMOVE /a", fs.Event{Path: "/a", Op: fs.Remove}.String())
+}